@@ -5,52 +5,271 @@ import (
 	"sort"
 )
 
+// AIStrategy は待機中のメダロットの行動（使用パーツのスロットとターゲット）を決める。
+// 乱数が必要な場合はバトルの rng ではなく game.aiRNG を使う（リプレイで命中判定がずれないように）。
+type AIStrategy interface {
+	SelectAction(game *Game, medarot *Medarot) (PartSlotKey, *Medarot, bool)
+}
+
 // aiSelectAction はAIメダロットの行動を決定し、チャージを開始させる
 func aiSelectAction(game *Game, medarot *Medarot) {
-	// 攻撃可能なパーツを取得
-	availableParts := medarot.GetAvailableAttackParts()
-	if len(availableParts) == 0 {
-		log.Printf("%s: AIは攻撃可能なパーツがないため待機。", medarot.Name)
+	strategy := game.strategyFor(medarot)
+	slotKey, target, ok := strategy.SelectAction(game, medarot)
+	if !ok {
 		return
 	}
+	game.commitAction(medarot, slotKey, target)
+}
 
-	// 攻撃対象の候補を取得
-	targetCandidates := getTargetCandidates(game, medarot)
-	if len(targetCandidates) == 0 {
-		log.Printf("%s: AIは攻撃対象がいないため待機。", medarot.Name)
-		return
+// strategyFor はメダロットのチームに割り当てられたAIを返す。未設定なら Normal の ScoringAI を割り当てる
+func (g *Game) strategyFor(medarot *Medarot) AIStrategy {
+	if strategy, ok := g.teamAI[medarot.Team]; ok {
+		return strategy
 	}
+	strategy := NewScoringAI(aiProfiles[AINormal])
+	g.teamAI[medarot.Team] = strategy
+	return strategy
+}
 
-	// === シンプルなAI思考ルーチン ===
-	// TODO: ここをより賢いロジックに拡張する
-	// 今回は、利用可能な最初のパーツで、相手のリーダーを優先して狙う
+// SetTeamAI はチームの行動を決めるAIを設定する
+func (g *Game) SetTeamAI(team TeamID, strategy AIStrategy) {
+	g.teamAI[team] = strategy
+}
 
-	// 1. ターゲット選択
-	var target *Medarot
-	for _, cand := range targetCandidates {
-		if cand.IsLeader {
-			target = cand
-			break
+// baseAI は行動ルール・外部ボットの Fallback をたどり、最終的に行動を決める一番下のAIを返す
+func baseAI(strategy AIStrategy) AIStrategy {
+	switch ai := strategy.(type) {
+	case *RuleBasedAI:
+		return baseAI(ai.Fallback)
+	case *ExternalBotAI:
+		return baseAI(ai.Fallback)
+	}
+	return strategy
+}
+
+// replaceBaseAI は行動ルール・外部ボットはそのままに、一番下のAIを base に差し替えたものを返す
+func replaceBaseAI(strategy AIStrategy, base AIStrategy) AIStrategy {
+	switch ai := strategy.(type) {
+	case *RuleBasedAI:
+		ai.Fallback = replaceBaseAI(ai.Fallback, base)
+		return ai
+	case *ExternalBotAI:
+		ai.Fallback = replaceBaseAI(ai.Fallback, base)
+		log.Printf("外部ボット (%s) はそのまま使い、返事が無いときのAIを替えます。", ai.Command)
+		return ai
+	}
+	return base
+}
+
+// =============================================================================
+// スコアリングAI (Utility AI)
+// =============================================================================
+
+// ScoringAI は全ての (パーツ, ターゲット) の組み合わせを期待値で採点し、最も良いものを選ぶ。
+// 採点の癖や判断の速さは AIProfile で決まる。
+type ScoringAI struct {
+	Profile AIProfile
+}
+
+// NewScoringAI は指定したプロファイルで ScoringAI を生成する
+func NewScoringAI(profile AIProfile) *ScoringAI {
+	return &ScoringAI{Profile: profile}
+}
+
+// 採点の重み
+const (
+	scoreHeadBreakValue   = 100.0 // 頭部を破壊して相手を機能停止させる価値（ダメージ換算）
+	scoreLeaderMultiplier = 3.0   // 相手リーダーの頭部破壊は勝利に直結するので価値を上げる
+)
+
+// scoredAction は採点済みの行動候補
+type scoredAction struct {
+	slot   PartSlotKey
+	part   *Part
+	target *Medarot
+	score  float64
+}
+
+// SelectAction は ScoringAI の AIStrategy 実装
+func (ai *ScoringAI) SelectAction(game *Game, medarot *Medarot) (PartSlotKey, *Medarot, bool) {
+	// 反応の遅いAIは、待機状態になってからしばらく様子を見る
+	if medarot.IdleTicks < ai.Profile.ReactionDelayTicks {
+		return "", nil, false
+	}
+	candidates := enumerateActions(game, medarot)
+	if len(candidates) == 0 {
+		game.logger.Printf("%s: AIは攻撃可能なパーツか攻撃対象がないため待機。", medarot.Name)
+		return "", nil, false
+	}
+	for i := range candidates {
+		candidates[i].score = scoreAction(game, medarot, candidates[i].part, candidates[i].target, &ai.Profile)
+	}
+	var chosen scoredAction
+	if len(candidates) > 1 && game.aiRNG.Float64() < ai.Profile.MistakeRate {
+		// わざと最善手以外を選ぶ
+		sortByScore(candidates)
+		chosen = candidates[1+game.aiRNG.IntN(len(candidates)-1)]
+	} else {
+		chosen = pickScoredAction(game, candidates, ai.Profile.Randomness)
+	}
+	game.logger.Printf("%s: AIは%sで%sを狙う (スコア %.2f)。", medarot.Name, chosen.part.PartName, chosen.target.Name, chosen.score)
+	return chosen.slot, chosen.target, true
+}
+
+// enumerateActions は使用可能なパーツと攻撃対象の全組み合わせを列挙する
+func enumerateActions(game *Game, medarot *Medarot) []scoredAction {
+	targets := getTargetCandidates(game, medarot)
+	var actions []scoredAction
+	for _, slot := range []PartSlotKey{PartSlotHead, PartSlotRightArm, PartSlotLeftArm} {
+		part := medarot.GetPart(slot)
+		if part == nil || part.IsBroken || part.Category == CategoryNone {
+			continue
+		}
+		for _, target := range targets {
+			actions = append(actions, scoredAction{slot: slot, part: part, target: target})
+		}
+	}
+	return actions
+}
+
+// scoreAction は1つの行動候補を「1秒あたりの期待価値」で採点する。
+// 期待ダメージ（命中率は calculateHit と同じ式）、頭部破壊の確率、
+// 自チームのリーダーへの脅威を評価し、チャージ時間で割る。
+func scoreAction(game *Game, medarot *Medarot, part *Part, target *Medarot, profile *AIProfile) float64 {
+	balance := &game.Config.Balance
+	hit := float64(hitChance(part, target, balance)) / 100
+	critRate := float64(medarot.criticalChance()) / 100
+	normalDamage := medarot.potentialDamage(part, balance, false)
+	criticalDamage := medarot.potentialDamage(part, balance, true)
+
+	// 当たったパーツの残り装甲以上のダメージは無駄になるので、パーツごとに上限をかけて平均する
+	vulnerable := vulnerableParts(target)
+	if len(vulnerable) == 0 {
+		return 0
+	}
+	expectedDamage := 0.0
+	headBreakChance := 0.0
+	for _, p := range vulnerable {
+		expectedDamage += (1-critRate)*float64(min(normalDamage, p.Armor)) + critRate*float64(min(criticalDamage, p.Armor))
+		if p.Type == PartTypeHead {
+			if normalDamage >= p.Armor {
+				headBreakChance = 1
+			} else if criticalDamage >= p.Armor {
+				headBreakChance = critRate
+			}
+		}
+	}
+	perPart := 1.0 / float64(len(vulnerable))
+	expectedDamage *= hit * perPart
+	headBreakChance *= hit * perPart
+
+	headValue := scoreHeadBreakValue
+	if target.IsLeader {
+		headValue *= scoreLeaderMultiplier
+	}
+	value := expectedDamage + headBreakChance*headValue
+	if isThreateningLeader(game, target) {
+		value *= profile.LeaderProtection
+	}
+	if profile.FocusFire > 0 {
+		value *= 1 + profile.FocusFire*float64(countAlliesTargeting(game, medarot, target))
+	}
+
+	actionTicks := medarot.chargeTicks(part, balance)
+	if profile.Lookahead {
+		// 相手の攻撃より先に頭部を壊せれば、その攻撃を丸ごと防げる
+		if remaining, threat := pendingThreat(game, target); remaining > actionTicks {
+			value += headBreakChance * threat
+		}
+		// 行動後のクールダウンも含めた1サイクルあたりの価値で比べる
+		actionTicks += cooldownTicks(part, balance)
+	}
+	return value / (actionTicks / SimulationTicksPerSecond)
+}
+
+// countAlliesTargeting は、同じ相手をすでに狙ってチャージ中の味方の数を返す
+func countAlliesTargeting(game *Game, medarot *Medarot, target *Medarot) int {
+	count := 0
+	for _, ally := range game.Medarots {
+		if ally == medarot || ally.Team != medarot.Team {
+			continue
 		}
+		if (ally.State == StateCharging || ally.State == StateReady) && ally.TargetedMedarot == target {
+			count++
+		}
+	}
+	return count
+}
+
+// pendingThreat は相手がチャージ中の攻撃が実行されるまでの残りティック数と、その攻撃の期待価値を返す
+func pendingThreat(game *Game, opponent *Medarot) (float64, float64) {
+	if opponent.State != StateCharging || opponent.TargetedMedarot == nil {
+		return 0, 0
+	}
+	part := opponent.GetPart(opponent.SelectedPartKey)
+	if part == nil {
+		return 0, 0
+	}
+	balance := &game.Config.Balance
+	hit := float64(hitChance(part, opponent.TargetedMedarot, balance)) / 100
+	threat := hit * float64(opponent.potentialDamage(part, balance, false))
+	if opponent.TargetedMedarot.IsLeader {
+		threat *= scoreLeaderMultiplier
 	}
-	if target == nil { // リーダーがいない（破壊された）場合
-		target = targetCandidates[0]
+	return opponent.TotalDuration - opponent.ProgressCounter, threat
+}
+
+// vulnerableParts はダメージを受けうる（破壊されていない）パーツを返す
+func vulnerableParts(m *Medarot) []*Part {
+	var parts []*Part
+	for _, slot := range []PartSlotKey{PartSlotHead, PartSlotRightArm, PartSlotLeftArm, PartSlotLegs} {
+		if part := m.GetPart(slot); part != nil && !part.IsBroken {
+			parts = append(parts, part)
+		}
 	}
+	return parts
+}
 
-	// 2. 使用パーツ選択
-	selectedPart := availableParts[0]
+// isThreateningLeader は相手が自チーム（相手から見た敵チーム）のリーダーを狙ってチャージ中かを返す
+func isThreateningLeader(game *Game, opponent *Medarot) bool {
+	if opponent.State != StateCharging && opponent.State != StateReady {
+		return false
+	}
+	return opponent.TargetedMedarot != nil && opponent.TargetedMedarot.IsLeader
+}
 
-	// 3. 選択したパーツのスロットキーを取得
-	var slotKey PartSlotKey
-	for s, p := range medarot.Parts {
-		if p.ID == selectedPart.ID {
-			slotKey = s
+// pickScoredAction はスコアが最高値の (1-randomness) 倍以上の候補から、スコアに比例した確率で1つ選ぶ
+func pickScoredAction(game *Game, candidates []scoredAction, randomness float64) scoredAction {
+	sortByScore(candidates)
+	best := candidates[0].score
+	if randomness <= 0 || best <= 0 {
+		return candidates[0]
+	}
+	threshold := best * (1 - randomness)
+	pool := candidates[:1]
+	total := candidates[0].score
+	for _, c := range candidates[1:] {
+		if c.score < threshold {
 			break
 		}
+		pool = append(pool, c)
+		total += c.score
+	}
+	roll := game.aiRNG.Float64() * total
+	for _, c := range pool {
+		roll -= c.score
+		if roll < 0 {
+			return c
+		}
 	}
+	return pool[len(pool)-1]
+}
 
-	// 4. 行動を決定し、チャージを開始
-	medarot.SelectAndStartCharge(slotKey, target, &game.Config.Balance)
+// sortByScore は候補をスコアの高い順に並べる
+func sortByScore(candidates []scoredAction) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
 }
 
 // getTargetCandidates は指定されたメダロットの攻撃対象候補リストを返す
@@ -72,4 +291,4 @@ func getTargetCandidates(game *Game, actingMedarot *Medarot) []*Medarot {
 		return candidates[i].DrawIndex < candidates[j].DrawIndex
 	})
 	return candidates
-}
\ No newline at end of file
+}
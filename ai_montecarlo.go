@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// モンテカルロAIの既定値
+const (
+	defaultMonteCarloRollouts = 32                            // 行動候補1つあたりのプレイアウト回数
+	defaultMonteCarloBudget   = 50 * time.Millisecond         // 1回の行動決定にかけてよい時間
+	defaultMonteCarloHorizon  = 60 * SimulationTicksPerSecond // 1回のプレイアウトで進める最大ティック数
+)
+
+// MonteCarloAI は行動決定のたびに現在のバトルを複製し、各 (パーツ, ターゲット) の候補について
+// 乱数の異なる未来を何度もプレイアウトして、勝率が最も高い行動を選ぶ。
+// プレイアウト中は両チームとも Rollout のAIで行動する。
+type MonteCarloAI struct {
+	Rollouts int           // 候補1つあたりのプレイアウト回数の上限
+	Budget   time.Duration // 1回の行動決定にかけてよい時間。超えたらその時点の勝率で決める（画面のある対戦中だけ。useTimeBudget を参照）
+	Horizon  int           // プレイアウトで進める最大ティック数。決着しなければ引き分け扱い
+	Rollout  AIStrategy    // プレイアウト中に両チームの行動を決める軽量なAI
+}
+
+// NewMonteCarloAI は既定の設定で MonteCarloAI を生成する
+func NewMonteCarloAI() *MonteCarloAI {
+	return &MonteCarloAI{
+		Rollouts: defaultMonteCarloRollouts,
+		Budget:   defaultMonteCarloBudget,
+		Horizon:  defaultMonteCarloHorizon,
+		Rollout:  NewScoringAI(AIProfile{Randomness: 0.3, LeaderProtection: 1.5}),
+	}
+}
+
+// monteCarloCandidate はプレイアウト中の行動候補と、その集計
+type monteCarloCandidate struct {
+	action scoredAction
+	wins   float64
+	plays  int
+}
+
+// SelectAction は MonteCarloAI の AIStrategy 実装
+func (ai *MonteCarloAI) SelectAction(game *Game, medarot *Medarot) (PartSlotKey, *Medarot, bool) {
+	actions := enumerateActions(game, medarot)
+	if len(actions) == 0 {
+		game.logger.Printf("%s: AIは攻撃可能なパーツか攻撃対象がないため待機。", medarot.Name)
+		return "", nil, false
+	}
+	if len(actions) == 1 {
+		return actions[0].slot, actions[0].target, true
+	}
+	candidates := make([]*monteCarloCandidate, len(actions))
+	for i, action := range actions {
+		candidates[i] = &monteCarloCandidate{action: action}
+	}
+
+	// 先読みの回数は時間で変わるので、AI用乱数からはシードを1つだけ取り出して使う
+	rolloutRNG := rand.New(rand.NewPCG(game.aiRNG.Uint64(), 0))
+	timed := ai.useTimeBudget(game)
+	deadline := time.Now().Add(ai.Budget)
+	// 全候補を1回ずつ順に試すのを繰り返し、時間切れでも候補ごとの試行回数が偏らないようにする。
+	// 同じ回では全候補に同じシードを使い、乱数の当たり外れではなく行動の差で比べる。
+	// プレイアウトはログを出さない複製で行うので、ログが溢れることはない（cloneForSimulation を参照）。
+	for round := 0; round < ai.Rollouts; round++ {
+		if timed && round > 0 && time.Now().After(deadline) {
+			break
+		}
+		seed := rolloutRNG.Uint64()
+		for _, c := range candidates {
+			c.wins += ai.playout(game, medarot, c.action, seed)
+			c.plays++
+		}
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.wins/float64(c.plays) > best.wins/float64(best.plays) {
+			best = c
+		}
+	}
+	game.logger.Printf("%s: AIは%sで%sを狙う (勝率 %.0f%%, %d回のプレイアウト)。",
+		medarot.Name, best.action.part.PartName, best.action.target.Name, best.wins/float64(best.plays)*100, best.plays)
+	return best.action.slot, best.action.target, true
+}
+
+// useTimeBudget は Budget の時間で先読みを打ち切ってよいかを返す。打ち切るのは画面のある対戦中だけで、
+// UI なしのシミュレーション（medasim・medatune・medaevo）やリプレイ再生では、マシンの速さで結果が
+// 変わらないよう常に Rollouts 回まで先読みし、同じシードなら同じ行動を選ぶ
+func (ai *MonteCarloAI) useTimeBudget(game *Game) bool {
+	return game.ui != nil && game.replay == nil
+}
+
+// playout はバトルの複製で候補の行動を実行し、決着まで進めた結果を返す（勝ち 1、負け 0、未決着 0.5）
+func (ai *MonteCarloAI) playout(game *Game, medarot *Medarot, action scoredAction, seed uint64) float64 {
+	sim := game.cloneForSimulation(seed, ai.Rollout)
+	actor := sim.findMedarotByID(medarot.ID)
+	target := sim.findMedarotByID(action.target.ID)
+	if !sim.commitAction(actor, action.slot, target) {
+		return 0
+	}
+	switch sim.runSimulation(ai.Horizon) {
+	case medarot.Team:
+		return 1
+	case TeamNone:
+		return 0.5
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import "fmt"
+
+// AIDifficulty はAIの難易度
+type AIDifficulty string
+
+const (
+	AIEasy   AIDifficulty = "easy"
+	AINormal AIDifficulty = "normal"
+	AIHard   AIDifficulty = "hard"
+	AIExpert AIDifficulty = "expert"
+)
+
+// AIProfile はAIの思考の癖をまとめた値。グローバル設定ではなく各AIに渡すので、
+// チームごとに異なる難易度を使える。
+type AIProfile struct {
+	Difficulty AIDifficulty
+	// Randomness は最高スコアからどれだけ低い手まで候補に残すかの割合（0なら常に最善手）
+	Randomness float64
+	// MistakeRate はわざと最善手以外から選ぶ確率
+	MistakeRate float64
+	// ReactionDelayTicks は待機状態になってから行動を決めるまでに待つティック数
+	ReactionDelayTicks int
+	// FocusFire は味方がすでに狙っている相手1体分ごとのスコア上乗せ率（集中攻撃）
+	FocusFire float64
+	// LeaderProtection は自チームのリーダーを狙っている相手へのスコア倍率
+	LeaderProtection float64
+	// Lookahead が有効なら、チャージ/クールダウンのタイムラインを先読みして評価する
+	Lookahead bool
+}
+
+// aiProfiles は難易度ごとの標準プロファイル
+var aiProfiles = map[AIDifficulty]AIProfile{
+	AIEasy: {
+		Difficulty:         AIEasy,
+		Randomness:         0.5,
+		MistakeRate:        0.3,
+		ReactionDelayTicks: SimulationTicksPerSecond,
+		LeaderProtection:   1.0,
+	},
+	AINormal: {
+		Difficulty:       AINormal,
+		Randomness:       0.1,
+		LeaderProtection: 1.5,
+	},
+	AIHard: {
+		Difficulty:       AIHard,
+		Randomness:       0.05,
+		FocusFire:        0.5,
+		LeaderProtection: 2.5,
+	},
+	AIExpert: {
+		Difficulty:       AIExpert,
+		FocusFire:        0.5,
+		LeaderProtection: 2.5,
+		Lookahead:        true,
+	},
+}
+
+// AIMonteCarlo はプレイアウトで行動を決める MonteCarloAI を選ぶための名前
+const AIMonteCarlo = "montecarlo"
+
+// NewTeamAI はコマンドライン等で指定された名前からチーム用のAIを生成する
+func NewTeamAI(name string) (AIStrategy, error) {
+	switch name {
+	case AIMonteCarlo:
+		return NewMonteCarloAI(), nil
+	case AITeamPlanner:
+		return NewTeamPlannerAI(aiProfiles[AIHard]), nil
+	}
+	profile, err := AIProfileFor(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewScoringAI(profile), nil
+}
+
+// AIProfileFor は難易度名からプロファイルを返す
+func AIProfileFor(name string) (AIProfile, error) {
+	profile, ok := aiProfiles[AIDifficulty(name)]
+	if !ok {
+		return AIProfile{}, fmt.Errorf("不明なAI難易度: %q (easy, normal, hard, expert, montecarlo, team のいずれか)", name)
+	}
+	return profile, nil
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ルールベースAIの行動ファイル (data/ai_rules.csv)
+//
+// 1行が1つのルールで、メダロットごとに上から順に評価し、条件を満たす最初のルールの行動を選ぶ。
+// どのルールにも当てはまらなければ、チームに設定された通常のAIが行動を決める。
+//
+//	medarot_id,conditions,part,target
+//	E-01,target_head_armor<30%,any,lowest_head_armor
+//	E-01,own_leader_under_attack,trait:AIM,attacker_of_leader
+//	*,,any,best
+//
+// medarot_id: medarots.csv のID。"*" は全メダロット共通（個別のルールの後に評価する）。
+// conditions: "&" 区切りの条件。すべて満たすと成立する。空なら常に成立。先頭の "!" で否定。
+// part:       使うパーツ。head / r_arm / l_arm / any / strongest / trait:<特性> / category:<種別>
+// target:     狙う相手。best / lowest_armor / lowest_head_armor / leader / attacker_of_leader
+
+// AIRule はルールファイルの1行を解釈したもの
+type AIRule struct {
+	Line       int
+	Record     []string // ファイルに書かれていた列。セーブデータに残し、再開時に同じルールを作り直す
+	MedarotID  string
+	Conditions []ruleCondition
+	Part       rulePartSelector
+	Target     ruleTargetSelector
+}
+
+// ruleContext は条件を評価するときの (自分, パーツ, 相手) の組
+type ruleContext struct {
+	game   *Game
+	self   *Medarot
+	slot   PartSlotKey
+	part   *Part
+	target *Medarot
+}
+
+type ruleCondition func(ctx *ruleContext) bool
+type rulePartSelector func(slot PartSlotKey, part *Part, candidates []scoredAction) bool
+
+// ruleTargetSelector は狙う相手の優先順位を返す。小さいほど優先し、ok が false なら対象外
+type ruleTargetSelector func(game *Game, self *Medarot, target *Medarot, targets []*Medarot) (rank float64, ok bool)
+
+// RuleBasedAI はルールファイルに書かれた順に条件を評価して行動を決める。
+// ルールの無いメダロットや、どのルールにも当てはまらない場合は Fallback に任せる。
+// 判断の速さと候補の採点には Fallback（の一番下のAI）のプロファイルを使うので、チームの難易度はルールに当てはまるメダロットにも効く。
+type RuleBasedAI struct {
+	Rules    map[string][]AIRule
+	Fallback AIStrategy
+}
+
+// NewRuleBasedAI はルールの一覧から RuleBasedAI を生成する
+func NewRuleBasedAI(rules []AIRule, fallback AIStrategy) *RuleBasedAI {
+	ai := &RuleBasedAI{Rules: make(map[string][]AIRule), Fallback: fallback}
+	for _, rule := range rules {
+		ai.Rules[rule.MedarotID] = append(ai.Rules[rule.MedarotID], rule)
+	}
+	return ai
+}
+
+// SelectAction は RuleBasedAI の AIStrategy 実装
+func (ai *RuleBasedAI) SelectAction(game *Game, medarot *Medarot) (PartSlotKey, *Medarot, bool) {
+	rules := append(append([]AIRule{}, ai.Rules[medarot.ID]...), ai.Rules["*"]...)
+	if len(rules) == 0 {
+		return ai.Fallback.SelectAction(game, medarot)
+	}
+	// Fallback が入れ替わることがある (SetAutoBattleAI) ので、プロファイルは毎回引き直す
+	profile := profileOf(ai.Fallback)
+	if medarot.IdleTicks < profile.ReactionDelayTicks {
+		return "", nil, false
+	}
+	candidates := enumerateActions(game, medarot)
+	targets := getTargetCandidates(game, medarot)
+	for _, rule := range rules {
+		if chosen, ok := applyRule(game, medarot, rule, candidates, targets, &profile); ok {
+			game.logger.Printf("%s: ルール (%d行目) に従い%sで%sを狙う。", medarot.Name, rule.Line, chosen.part.PartName, chosen.target.Name)
+			return chosen.slot, chosen.target, true
+		}
+	}
+	return ai.Fallback.SelectAction(game, medarot)
+}
+
+// profileOf は strategy の一番下のAIのプロファイルを返す。プロファイルを持たないAI（モンテカルロ）は normal のものを使う
+func profileOf(strategy AIStrategy) AIProfile {
+	switch ai := baseAI(strategy).(type) {
+	case *ScoringAI:
+		return ai.Profile
+	case *TeamPlannerAI:
+		return ai.Profile
+	}
+	return aiProfiles[AINormal]
+}
+
+// applyRule はルールに当てはまる (パーツ, 相手) の組から、相手の優先順位が最も高く、その中でスコアが最も高いものを選ぶ
+func applyRule(game *Game, medarot *Medarot, rule AIRule, candidates []scoredAction, targets []*Medarot, profile *AIProfile) (scoredAction, bool) {
+	var best scoredAction
+	bestRank := 0.0
+	found := false
+	for _, c := range candidates {
+		if !rule.Part(c.slot, c.part, candidates) {
+			continue
+		}
+		rank, ok := rule.Target(game, medarot, c.target, targets)
+		if !ok {
+			continue
+		}
+		ctx := &ruleContext{game: game, self: medarot, slot: c.slot, part: c.part, target: c.target}
+		if !allConditions(rule.Conditions, ctx) {
+			continue
+		}
+		c.score = scoreAction(game, medarot, c.part, c.target, profile)
+		if !found || rank < bestRank || (rank == bestRank && c.score > best.score) {
+			best, bestRank, found = c, rank, true
+		}
+	}
+	return best, found
+}
+
+func allConditions(conditions []ruleCondition, ctx *ruleContext) bool {
+	for _, cond := range conditions {
+		if !cond(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// aiRuleColumns はルールファイルの見出し行
+var aiRuleColumns = []string{"medarot_id", "conditions", "part", "target"}
+
+// LoadAIRules は src のルールファイル name を読み込む。解釈できない行があれば、すべての問題をまとめたエラーを返す
+func LoadAIRules(src dataSource, name string) ([]AIRule, error) {
+	filePath := src.path(name)
+	data, err := fs.ReadFile(src.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff")) // Excel が付ける BOM を除く
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	if !slices.Equal(header, aiRuleColumns) {
+		headerLine, _ := reader.FieldPos(0)
+		return nil, fmt.Errorf("%s:%d: 見出し行が '%s' ではありません ('%s')", filePath, headerLine,
+			strings.Join(aiRuleColumns, ","), strings.Join(header, ","))
+	}
+
+	var rules []AIRule
+	var problems []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 引用符の閉じ忘れなども、行番号付きで報告して次の行へ進む
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				problems = append(problems, fmt.Errorf("%s: %w", filePath, err))
+				break
+			}
+			problems = append(problems, fmt.Errorf("%s:%d: %w", filePath, parseErr.StartLine, parseErr.Err))
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		rule, errs := parseAIRule(record, line)
+		for _, e := range errs {
+			problems = append(problems, fmt.Errorf("%s:%d: %w", filePath, line, e))
+		}
+		if len(errs) == 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, errors.Join(problems...)
+}
+
+// ValidateAIRules はルールファイルを検証し、見つかった問題を1つずつ返す。
+// 書式の誤りに加えて、medarots.csv に存在しないメダロットIDも報告する。
+func ValidateAIRules(src dataSource, name string, gameData *GameData) []error {
+	filePath := src.path(name)
+	rules, err := LoadAIRules(src, name)
+	var problems []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		problems = joined.Unwrap()
+	} else if err != nil {
+		return []error{err}
+	}
+	known := make(map[string]bool)
+	for _, loadout := range gameData.Medarots {
+		known[loadout.ID] = true
+	}
+	for _, rule := range rules {
+		if rule.MedarotID != "*" && !known[rule.MedarotID] {
+			problems = append(problems, fmt.Errorf("%s:%d: 不明なメダロットID '%s'", filePath, rule.Line, rule.MedarotID))
+		}
+	}
+	return problems
+}
+
+// parseAIRule はルールファイルの1行を解釈する。問題はすべて集めて返す
+func parseAIRule(record []string, line int) (AIRule, []error) {
+	var errs []error
+	if len(record) < 4 {
+		return AIRule{}, []error{fmt.Errorf("列が足りません (medarot_id,conditions,part,target の4列が必要)")}
+	}
+	rule := AIRule{Line: line, Record: slices.Clone(record[:4]), MedarotID: strings.TrimSpace(record[0])}
+	if rule.MedarotID == "" {
+		errs = append(errs, fmt.Errorf("medarot_id が空です"))
+	}
+	for _, text := range strings.Split(record[1], "&") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		cond, err := parseRuleCondition(text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rule.Conditions = append(rule.Conditions, cond)
+	}
+	var err error
+	if rule.Part, err = parseRulePart(record[2]); err != nil {
+		errs = append(errs, err)
+	}
+	if rule.Target, err = parseRuleTarget(record[3]); err != nil {
+		errs = append(errs, err)
+	}
+	return rule, errs
+}
+
+var ruleConditionPattern = regexp.MustCompile(`^(!?)\s*([a-z_]+)\s*(?:(<=|>=|!=|<|>|=)\s*(.+))?$`)
+
+// ruleNumbers は数値で比べられる条件。percent が true なら "30%" のように最大値に対する割合でも書ける
+var ruleNumbers = map[string]struct {
+	value   func(ctx *ruleContext) (current, max int)
+	percent bool
+}{
+	"target_head_armor": {func(ctx *ruleContext) (int, int) { return headArmor(ctx.target) }, true},
+	"target_armor":      {func(ctx *ruleContext) (int, int) { return totalArmor(ctx.target) }, true},
+	"self_head_armor":   {func(ctx *ruleContext) (int, int) { return headArmor(ctx.self) }, true},
+	"self_armor":        {func(ctx *ruleContext) (int, int) { return totalArmor(ctx.self) }, true},
+	"part_power":        {func(ctx *ruleContext) (int, int) { return ctx.part.Power, 0 }, false},
+	"part_charge":       {func(ctx *ruleContext) (int, int) { return ctx.part.Charge, 0 }, false},
+}
+
+// ruleStrings は文字列で一致を調べる条件
+var ruleStrings = map[string]func(ctx *ruleContext) string{
+	"part_trait":    func(ctx *ruleContext) string { return string(ctx.part.Trait) },
+	"part_category": func(ctx *ruleContext) string { return string(ctx.part.Category) },
+	"part_slot":     func(ctx *ruleContext) string { return string(ctx.slot) },
+}
+
+// ruleStringValues は ruleStrings の条件や trait: / category: のパーツの指定に書ける値。
+// パーツデータの action_trait / action_category と同じ値で、それ以外は書き間違いとしてエラーにする
+var ruleStringValues = map[string][]string{
+	"part_trait":    {string(TraitAim), string(TraitStrike), string(TraitBerserk), string(TraitNormal), string(TraitNone)},
+	"part_category": {string(CategoryShoot), string(CategoryMelee), string(CategoryNone)},
+	"part_slot":     {string(PartSlotHead), string(PartSlotRightArm), string(PartSlotLeftArm)},
+}
+
+// checkRuleValue は key の条件に書かれた値 value が使える値かを確かめる
+func checkRuleValue(key, value string) error {
+	allowed := ruleStringValues[key]
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("%s の値 '%s' は不明です (%s のいずれか)", key, value, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// ruleFlags は値を取らない条件
+var ruleFlags = map[string]ruleCondition{
+	"own_leader_under_attack": func(ctx *ruleContext) bool { return leaderUnderAttack(ctx.game, ctx.self.Team) },
+	"target_is_leader":        func(ctx *ruleContext) bool { return ctx.target.IsLeader },
+	"target_attacking_leader": func(ctx *ruleContext) bool { return isThreateningLeader(ctx.game, ctx.target) },
+	"self_is_leader":          func(ctx *ruleContext) bool { return ctx.self.IsLeader },
+}
+
+// parseRuleCondition は "target_head_armor<30%" や "!own_leader_under_attack" のような条件を解釈する
+func parseRuleCondition(text string) (ruleCondition, error) {
+	text = strings.TrimSpace(text)
+	m := ruleConditionPattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil, fmt.Errorf("条件 '%s' を解釈できません", text)
+	}
+	negate, key, op, value := m[1] == "!", m[2], m[3], strings.TrimSpace(m[4])
+
+	var cond ruleCondition
+	if flag, ok := ruleFlags[key]; ok {
+		if op != "" {
+			return nil, fmt.Errorf("条件 '%s' は値を取りません", key)
+		}
+		cond = flag
+	} else if get, ok := ruleStrings[key]; ok {
+		if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("条件 '%s' は = か != で比べてください", key)
+		}
+		want := strings.ToUpper(value)
+		if key == "part_slot" {
+			want = value
+		}
+		if err := checkRuleValue(key, want); err != nil {
+			return nil, err
+		}
+		equal := op == "="
+		cond = func(ctx *ruleContext) bool { return (get(ctx) == want) == equal }
+	} else if number, ok := ruleNumbers[key]; ok {
+		if op == "" {
+			return nil, fmt.Errorf("条件 '%s' には比較 (<, <=, >, >=, =, !=) と値が必要です", key)
+		}
+		percent := strings.HasSuffix(value, "%")
+		if percent && !number.percent {
+			return nil, fmt.Errorf("条件 '%s' は割合 (%%) で比べられません", key)
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("条件 '%s' の値 '%s' が数値ではありません", key, value)
+		}
+		cond = func(ctx *ruleContext) bool {
+			current, max := number.value(ctx)
+			v := float64(current)
+			if percent {
+				if max <= 0 {
+					return false
+				}
+				v = v * 100 / float64(max)
+			}
+			return compareRuleNumber(v, op, threshold)
+		}
+	} else {
+		return nil, fmt.Errorf("不明な条件 '%s'", key)
+	}
+	if negate {
+		inner := cond
+		cond = func(ctx *ruleContext) bool { return !inner(ctx) }
+	}
+	return cond, nil
+}
+
+func compareRuleNumber(v float64, op string, threshold float64) bool {
+	switch op {
+	case "<":
+		return v < threshold
+	case "<=":
+		return v <= threshold
+	case ">":
+		return v > threshold
+	case ">=":
+		return v >= threshold
+	case "=":
+		return v == threshold
+	default: // "!="
+		return v != threshold
+	}
+}
+
+// parseRulePart は使うパーツの指定を解釈する
+func parseRulePart(text string) (rulePartSelector, error) {
+	text = strings.TrimSpace(text)
+	name, arg, _ := strings.Cut(text, ":")
+	switch name {
+	case string(PartSlotHead), string(PartSlotRightArm), string(PartSlotLeftArm):
+		return func(slot PartSlotKey, _ *Part, _ []scoredAction) bool { return slot == PartSlotKey(name) }, nil
+	case "any":
+		return func(PartSlotKey, *Part, []scoredAction) bool { return true }, nil
+	case "strongest":
+		return func(_ PartSlotKey, part *Part, candidates []scoredAction) bool {
+			for _, c := range candidates {
+				if c.part.Power > part.Power {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "trait":
+		trait := Trait(strings.ToUpper(arg))
+		if err := checkRuleValue("part_trait", string(trait)); err != nil {
+			return nil, fmt.Errorf("パーツの指定 '%s': %w", text, err)
+		}
+		return func(_ PartSlotKey, part *Part, _ []scoredAction) bool { return part.Trait == trait }, nil
+	case "category":
+		category := PartCategory(strings.ToUpper(arg))
+		if err := checkRuleValue("part_category", string(category)); err != nil {
+			return nil, fmt.Errorf("パーツの指定 '%s': %w", text, err)
+		}
+		return func(_ PartSlotKey, part *Part, _ []scoredAction) bool { return part.Category == category }, nil
+	}
+	return nil, fmt.Errorf("不明なパーツの指定 '%s'", text)
+}
+
+// parseRuleTarget は狙う相手の指定を解釈する
+func parseRuleTarget(text string) (ruleTargetSelector, error) {
+	switch text = strings.TrimSpace(text); text {
+	case "best":
+		return func(*Game, *Medarot, *Medarot, []*Medarot) (float64, bool) { return 0, true }, nil
+	case "lowest_armor", "lowest_head_armor":
+		armor := totalArmor
+		if text == "lowest_head_armor" {
+			armor = headArmor
+		}
+		return func(_ *Game, _ *Medarot, target *Medarot, _ []*Medarot) (float64, bool) {
+			current, max := armor(target)
+			if max <= 0 {
+				return 0, true
+			}
+			return float64(current) / float64(max), true
+		}, nil
+	case "leader":
+		return func(_ *Game, _ *Medarot, target *Medarot, _ []*Medarot) (float64, bool) { return 0, target.IsLeader }, nil
+	case "attacker_of_leader":
+		return func(game *Game, _ *Medarot, target *Medarot, _ []*Medarot) (float64, bool) {
+			return 0, isThreateningLeader(game, target)
+		}, nil
+	}
+	return nil, fmt.Errorf("不明なターゲットの指定 '%s'", text)
+}
+
+// headArmor は頭部の残り装甲と最大装甲を返す
+func headArmor(m *Medarot) (int, int) {
+	head := m.GetPart(PartSlotHead)
+	if head == nil {
+		return 0, 0
+	}
+	return head.Armor, head.MaxArmor
+}
+
+// totalArmor は全パーツの残り装甲と最大装甲の合計を返す
+func totalArmor(m *Medarot) (int, int) {
+	current, max := 0, 0
+	for _, part := range m.Parts {
+		current += part.Armor
+		max += part.MaxArmor
+	}
+	return current, max
+}
+
+// leaderUnderAttack はチームのリーダーを狙ってチャージ中の相手がいるかを返す
+func leaderUnderAttack(game *Game, team TeamID) bool {
+	for _, m := range game.Medarots {
+		if m.Team != team && isThreateningLeader(game, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+// TestRuleBasedAIUsesFallbackProfile は行動ルールに当てはまるメダロットも、チームの難易度の反応の遅さで動くことを確かめる
+func TestRuleBasedAIUsesFallbackProfile(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestBattle(t, data, string(AIEasy))
+	rule, problems := parseAIRule([]string{"E-03", "", "category:FIGHT", "lowest_head_armor"}, 2)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	ai := NewRuleBasedAI([]AIRule{rule}, NewScoringAI(aiProfiles[AIEasy]))
+	m := g.findMedarotByID("E-03")
+	if m == nil {
+		t.Fatal("E-03 がいません")
+	}
+	delay := aiProfiles[AIEasy].ReactionDelayTicks
+
+	m.IdleTicks = delay - 1
+	_, _, ok := ai.SelectAction(g, m)
+	if ok {
+		t.Errorf("easy の反応の遅さ (%dティック) を待たずに行動しました", delay)
+	}
+	m.IdleTicks = delay
+	slot, _, ok := ai.SelectAction(g, m)
+	if !ok || m.GetPart(slot).Category != CategoryMelee {
+		t.Errorf("ルールに従って格闘パーツを選びませんでした (%s, %v)", slot, ok)
+	}
+}
+
+// TestProfileOfWrappedAI は行動ルールや外部ボットに包まれたAIから、一番下のAIのプロファイルを引けることを確かめる
+func TestProfileOfWrappedAI(t *testing.T) {
+	wrapped := NewRuleBasedAI(nil, &ExternalBotAI{Fallback: NewTeamPlannerAI(aiProfiles[AIHard])})
+	if got := profileOf(wrapped).Difficulty; got != AIHard {
+		t.Errorf("profileOf = %s, want %s", got, AIHard)
+	}
+	if got := profileOf(NewMonteCarloAI()).Difficulty; got != AINormal {
+		t.Errorf("モンテカルロの profileOf = %s, want %s", got, AINormal)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"slices"
+	"sort"
+)
+
+// AITeamPlanner はチーム全体で攻撃目標を割り振る TeamPlannerAI を選ぶための名前
+const AITeamPlanner = "team"
+
+// TeamPlannerAI はチーム単位で攻撃目標を割り振るAI。各メダロットが個別に最善手を探すのではなく、
+//   - 自チームのリーダーを狙っている相手には、それぞれ一番有効な味方を1体ずつ当てて脅威を分担し、
+//   - 残りの味方は頭部が最も弱っている相手に集中攻撃させる。
+//
+// 計画はどこかのパーツが破壊されたとき、リーダーを狙っている相手の顔ぶれが変わったとき
+// （または割り当てた相手が倒れたとき）に立て直す。
+// 防御行動はまだ無いので、リーダーの護衛は「リーダーを狙う相手を先に叩く」ことで代える。
+type TeamPlannerAI struct {
+	Profile AIProfile
+	// plan はメダロットIDから攻撃目標のメダロットIDへの割り当て
+	plan map[string]string
+	// planBrokenParts は計画を立てた時点で破壊されていたパーツの数。増えたら計画を立て直す
+	planBrokenParts int
+	// planThreats は計画を立てた時点で自チームのリーダーを狙っていた相手のID。顔ぶれが変わったら計画を立て直す
+	planThreats []string
+}
+
+// NewTeamPlannerAI は指定したプロファイルで TeamPlannerAI を生成する。
+// パーツの採点にはプロファイルを使い、集中攻撃は計画側で行うので FocusFire は使わない。
+func NewTeamPlannerAI(profile AIProfile) *TeamPlannerAI {
+	profile.FocusFire = 0
+	return &TeamPlannerAI{Profile: profile, planBrokenParts: -1}
+}
+
+// SelectAction は TeamPlannerAI の AIStrategy 実装
+func (ai *TeamPlannerAI) SelectAction(game *Game, medarot *Medarot) (PartSlotKey, *Medarot, bool) {
+	if medarot.IdleTicks < ai.Profile.ReactionDelayTicks {
+		return "", nil, false
+	}
+	if ai.needsReplan(game, medarot) {
+		ai.replan(game, medarot.Team)
+	}
+	target := game.findMedarotByID(ai.plan[medarot.ID])
+	best, ok := ai.bestActionAgainst(game, medarot, target)
+	if !ok {
+		game.logger.Printf("%s: AIは攻撃可能なパーツか攻撃対象がないため待機。", medarot.Name)
+		return "", nil, false
+	}
+	game.logger.Printf("%s: チームの計画に従い%sで%sを狙う (スコア %.2f)。", medarot.Name, best.part.PartName, best.target.Name, best.score)
+	return best.slot, best.target, true
+}
+
+// needsReplan は計画を立て直す必要があるかを返す
+func (ai *TeamPlannerAI) needsReplan(game *Game, medarot *Medarot) bool {
+	if countBrokenParts(game) != ai.planBrokenParts {
+		return true
+	}
+	if !slices.Equal(leaderThreatIDs(game, medarot.Team), ai.planThreats) {
+		return true
+	}
+	target := game.findMedarotByID(ai.plan[medarot.ID])
+	return target == nil || target.State == StateBroken
+}
+
+// replan はチーム全員の攻撃目標を割り振り直す
+func (ai *TeamPlannerAI) replan(game *Game, team TeamID) {
+	ai.plan = make(map[string]string)
+	ai.planBrokenParts = countBrokenParts(game)
+	ai.planThreats = leaderThreatIDs(game, team)
+
+	var members []*Medarot
+	for _, m := range game.Medarots {
+		if m.Team == team && m.State != StateBroken {
+			members = append(members, m)
+		}
+	}
+	if len(members) == 0 {
+		return
+	}
+	enemies := getTargetCandidates(game, members[0])
+	if len(enemies) == 0 {
+		return
+	}
+
+	// 1. 自チームのリーダーへの脅威を、攻撃が届くまでの時間が短い順に、1体ずつ味方に割り振る
+	var threats []*Medarot
+	for _, e := range enemies {
+		if isThreateningLeader(game, e) {
+			threats = append(threats, e)
+		}
+	}
+	sort.SliceStable(threats, func(i, j int) bool {
+		ri, _ := pendingThreat(game, threats[i])
+		rj, _ := pendingThreat(game, threats[j])
+		return ri < rj
+	})
+	assigned := make(map[*Medarot]bool)
+	for _, threat := range threats {
+		var bestMember *Medarot
+		bestScore := 0.0
+		for _, m := range members {
+			if assigned[m] {
+				continue
+			}
+			if action, ok := ai.bestActionAgainst(game, m, threat); ok && (bestMember == nil || action.score > bestScore) {
+				bestMember, bestScore = m, action.score
+			}
+		}
+		if bestMember == nil {
+			break
+		}
+		ai.plan[bestMember.ID] = threat.ID
+		assigned[bestMember] = true
+	}
+
+	// 2. 残りの味方は、頭部が最も弱っている相手に集中する
+	focus := weakestHeadTarget(enemies)
+	for _, m := range members {
+		if !assigned[m] {
+			ai.plan[m.ID] = focus.ID
+		}
+	}
+	game.logger.Printf("チーム%dの作戦: 脅威%d体に対応し、残りは%sに集中攻撃。", team+1, len(threats), focus.Name)
+}
+
+// bestActionAgainst は指定した相手に対して最もスコアの高いパーツを選ぶ。相手が攻撃できない場合は全候補から選ぶ。
+func (ai *TeamPlannerAI) bestActionAgainst(game *Game, medarot *Medarot, target *Medarot) (scoredAction, bool) {
+	var best scoredAction
+	found := false
+	candidates := enumerateActions(game, medarot)
+	for pass := 0; pass < 2 && !found; pass++ {
+		for _, c := range candidates {
+			if pass == 0 && c.target != target {
+				continue
+			}
+			c.score = scoreAction(game, medarot, c.part, c.target, &ai.Profile)
+			if !found || c.score > best.score {
+				best, found = c, true
+			}
+		}
+	}
+	return best, found
+}
+
+// weakestHeadTarget は頭部の残り装甲が最も少ない相手を返す。リーダーは勝敗に直結するので装甲を割り引いて比べる。
+func weakestHeadTarget(enemies []*Medarot) *Medarot {
+	var weakest *Medarot
+	weakestArmor := 0.0
+	for _, e := range enemies {
+		head := e.GetPart(PartSlotHead)
+		if head == nil {
+			continue
+		}
+		armor := float64(head.Armor)
+		if e.IsLeader {
+			armor /= scoreLeaderMultiplier
+		}
+		if weakest == nil || armor < weakestArmor {
+			weakest, weakestArmor = e, armor
+		}
+	}
+	if weakest == nil {
+		return enemies[0]
+	}
+	return weakest
+}
+
+// leaderThreatIDs は team のリーダーを狙ってチャージ中の相手のIDを、game.Medarots の順に返す
+func leaderThreatIDs(game *Game, team TeamID) []string {
+	var ids []string
+	for _, m := range game.Medarots {
+		if m.Team != team && m.State != StateBroken && isThreateningLeader(game, m) {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+// countBrokenParts はバトル全体で破壊されているパーツの数を返す
+func countBrokenParts(game *Game) int {
+	count := 0
+	for _, m := range game.Medarots {
+		for _, part := range m.Parts {
+			if part.IsBroken {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,76 @@
+package main
+
+// autoBattleAIChoices は「おまかせ」のAIとしてメニューから順に切り替えられる選択肢
+var autoBattleAIChoices = []string{
+	string(AIEasy), string(AINormal), string(AIHard), string(AIExpert), AITeamPlanner, AIMonteCarlo,
+}
+
+// isAutoBattle はプレイヤーのメダロットが「おまかせ」でAIに行動を任されているかを返す
+func (g *Game) isAutoBattle(m *Medarot) bool {
+	return m.Team == g.PlayerTeam && g.autoBattle[m.ID]
+}
+
+// SetAutoBattle はプレイヤーのメダロット1体の「おまかせ」を切り替える。
+// 行動選択中のメダロットを任せた場合は、その場でモーダルを閉じてAIに選ばせる。
+// 解除した場合は、次に待機状態になったときから再びプレイヤーが選ぶ。
+func (g *Game) SetAutoBattle(m *Medarot, on bool) {
+	if m.Team != g.PlayerTeam {
+		return
+	}
+	g.autoBattle[m.ID] = on
+	g.logger.Printf("%s: おまかせを%sにしました。", m.Name, onOff(on))
+	if on && g.playerMedarotToAct == m {
+		g.finishPlayerSelection()
+		if g.State == StatePlaying {
+			g.processIdleMedarots()
+		}
+	}
+}
+
+// SetTeamAutoBattle はプレイヤーチーム全員の「おまかせ」をまとめて切り替える
+func (g *Game) SetTeamAutoBattle(on bool) {
+	for _, m := range g.Medarots {
+		if m.Team == g.PlayerTeam {
+			g.SetAutoBattle(m, on)
+		}
+	}
+}
+
+// isTeamAutoBattle はプレイヤーチーム全員が「おまかせ」になっているかを返す
+func (g *Game) isTeamAutoBattle() bool {
+	for _, m := range g.Medarots {
+		if m.Team == g.PlayerTeam && !g.autoBattle[m.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetAutoBattleAI は「おまかせ」で使うAIを名前（難易度など）で選ぶ。
+// 行動ルールや外部ボット（-bot1）を使っている場合はそれを残し、その代わりに使うAIだけを替える
+func (g *Game) SetAutoBattleAI(name string) error {
+	strategy, err := NewTeamAI(name)
+	if err != nil {
+		return err
+	}
+	g.SetTeamAI(g.PlayerTeam, replaceBaseAI(g.teamAI[g.PlayerTeam], strategy))
+	g.autoBattleAI = name
+	return nil
+}
+
+// nextAutoBattleAI は選択肢の中で現在のAIの次の名前を返す
+func (g *Game) nextAutoBattleAI() string {
+	for i, name := range autoBattleAIChoices {
+		if name == g.autoBattleAI {
+			return autoBattleAIChoices[(i+1)%len(autoBattleAIChoices)]
+		}
+	}
+	return autoBattleAIChoices[0]
+}
+
+func onOff(on bool) string {
+	if on {
+		return "ON"
+	}
+	return "OFF"
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultSimulationMaxTicks は一括シミュレーションで1バトルに許す最大ティック数（10分）。超えたら引き分け
+const defaultSimulationMaxTicks = 10 * 60 * SimulationTicksPerSecond
+
+// SimulationOptions は UI なしでまとめてバトルを行うときの設定
+type SimulationOptions struct {
+	GameData *GameData
+	Balance  BalanceConfig
+	Team1    []MedarotData // チーム1の編成。先頭がリーダー
+	Team2    []MedarotData // チーム2の編成。先頭がリーダー
+	AI1      string        // チーム1のAI名 (NewTeamAI に渡す)
+	AI2      string        // チーム2のAI名
+	Rules    []AIRule      // メダロットごとの行動ルール（無ければ nil）
+	Mode     BattleMode
+	Seed     uint64 // i 戦目は Seed+i で戦う
+	Battles  int
+	MaxTicks int
+	Workers  int
+}
+
+// BattleOutcome は1バトルの結果
+type BattleOutcome struct {
+	Seed    uint64
+	Winner  TeamID // 決着しなかった場合は TeamNone
+	Ticks   int
+	Results []ActionResult
+}
+
+// SimulationReport は一括シミュレーションの集計結果
+type SimulationReport struct {
+	Battles      int
+	Team1Wins    int
+	Team2Wins    int
+	Draws        int
+	Team1WinRate float64
+	Team2WinRate float64
+	AvgTicks     float64
+	AvgSeconds   float64
+	Parts        []PartStats
+	Traits       []TraitStats
+	BrokenParts  []BrokenPartStats
+}
+
+// PartStats はパーツごとの使用回数と与えたダメージ
+type PartStats struct {
+	PartID    string
+	PartName  string
+	Uses      int
+	Hits      int
+	Damage    int
+	AvgDamage float64 // 1回の使用あたりのダメージ
+}
+
+// TraitStats は特性ごとの使用回数と命中率
+type TraitStats struct {
+	Trait    Trait
+	Uses     int
+	Attempts int // ターゲットが行動可能で、命中判定まで進んだ回数
+	Hits     int
+	HitRate  float64
+	UseRate  float64 // 全行動に占める割合
+}
+
+// BrokenPartStats は破壊されたパーツの集計
+type BrokenPartStats struct {
+	PartID   string
+	PartName string
+	Broken   int
+}
+
+// RunSimulations は指定した回数のバトルを UI なしで行い、集計結果を返す
+func RunSimulations(opts SimulationOptions) (*SimulationReport, error) {
+	if opts.Battles <= 0 {
+		return nil, fmt.Errorf("バトル数は1以上にしてください: %d", opts.Battles)
+	}
+	if len(opts.Team1) == 0 || len(opts.Team2) == 0 {
+		return nil, fmt.Errorf("両チームに1体以上のメダロットが必要です")
+	}
+	for _, name := range []string{opts.AI1, opts.AI2} {
+		if _, err := NewTeamAI(name); err != nil {
+			return nil, err
+		}
+	}
+	if opts.MaxTicks <= 0 {
+		opts.MaxTicks = defaultSimulationMaxTicks
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	outcomes := make([]BattleOutcome, opts.Battles)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				outcomes[i] = runSimulatedBattle(opts, opts.Seed+uint64(i))
+			}
+		}()
+	}
+	for i := 0; i < opts.Battles; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return summarizeOutcomes(outcomes), nil
+}
+
+// runSimulatedBattle は1バトルを決着まで（または MaxTicks まで）進める
+func runSimulatedBattle(opts SimulationOptions, seed uint64) BattleOutcome {
+	g := newSimulatedBattle(opts, seed)
+	winner := g.runSimulation(opts.MaxTicks)
+	outcome := BattleOutcome{Seed: seed, Winner: winner, Ticks: g.TickCount}
+	for _, entry := range g.battleLog {
+		if entry.Kind == LogKindAction && entry.Result != nil {
+			outcome.Results = append(outcome.Results, *entry.Result)
+		}
+	}
+	return outcome
+}
+
+// newSimulatedBattle は両チームをAIに任せた UI なしのバトルを作る
+func newSimulatedBattle(opts SimulationOptions, seed uint64) *Game {
+	data := *opts.GameData
+	data.Medarots = arrangeTeams(opts.Team1, opts.Team2)
+	config := Config{Balance: opts.Balance}
+	g := newBattle(&data, config, quietLogger)
+	g.PlayerTeam = TeamNone
+	g.BattleMode = opts.Mode
+	g.SetSeed(seed)
+	for team, name := range map[TeamID]string{Team1: opts.AI1, Team2: opts.AI2} {
+		strategy, _ := NewTeamAI(name) // 名前は RunSimulations で検証済み
+		if len(opts.Rules) > 0 {
+			strategy = NewRuleBasedAI(opts.Rules, strategy)
+		}
+		g.SetTeamAI(team, strategy)
+	}
+	return g
+}
+
+// arrangeTeams は2つの編成をチーム番号・リーダー・並び順を振り直して1つにまとめる。
+// 同じ編成同士（ミラーマッチ）でもIDが重ならないよう、重複したIDには "#2" のような番号を付ける。
+func arrangeTeams(team1, team2 []MedarotData) []MedarotData {
+	var all []MedarotData
+	used := make(map[string]int)
+	for team, loadouts := range [][]MedarotData{team1, team2} {
+		for i, loadout := range loadouts {
+			loadout.Team = TeamID(team)
+			loadout.IsLeader = i == 0
+			loadout.DrawIndex = i
+			used[loadout.ID]++
+			if n := used[loadout.ID]; n > 1 {
+				loadout.ID = fmt.Sprintf("%s#%d", loadout.ID, n)
+			}
+			all = append(all, loadout)
+		}
+	}
+	return all
+}
+
+// summarizeOutcomes はバトルの結果を集計する
+func summarizeOutcomes(outcomes []BattleOutcome) *SimulationReport {
+	report := &SimulationReport{Battles: len(outcomes)}
+	parts := make(map[string]*PartStats)
+	traits := make(map[Trait]*TraitStats)
+	broken := make(map[string]*BrokenPartStats)
+	totalTicks, totalUses := 0, 0
+	for _, outcome := range outcomes {
+		switch outcome.Winner {
+		case Team1:
+			report.Team1Wins++
+		case Team2:
+			report.Team2Wins++
+		default:
+			report.Draws++
+		}
+		totalTicks += outcome.Ticks
+		for _, r := range outcome.Results {
+			totalUses++
+			p, ok := parts[r.PartID]
+			if !ok {
+				p = &PartStats{PartID: r.PartID, PartName: r.PartName}
+				parts[r.PartID] = p
+			}
+			t, ok := traits[r.Trait]
+			if !ok {
+				t = &TraitStats{Trait: r.Trait}
+				traits[r.Trait] = t
+			}
+			p.Uses++
+			t.Uses++
+			if r.Executed {
+				t.Attempts++
+			}
+			if r.Hit {
+				p.Hits++
+				t.Hits++
+				p.Damage += r.Damage
+			}
+			if r.PartBroken {
+				b, ok := broken[r.TargetPartID]
+				if !ok {
+					b = &BrokenPartStats{PartID: r.TargetPartID, PartName: r.TargetPartName}
+					broken[r.TargetPartID] = b
+				}
+				b.Broken++
+			}
+		}
+	}
+	n := float64(len(outcomes))
+	report.Team1WinRate = float64(report.Team1Wins) / n
+	report.Team2WinRate = float64(report.Team2Wins) / n
+	report.AvgTicks = float64(totalTicks) / n
+	report.AvgSeconds = report.AvgTicks / SimulationTicksPerSecond
+
+	for _, p := range parts {
+		p.AvgDamage = float64(p.Damage) / float64(p.Uses)
+		report.Parts = append(report.Parts, *p)
+	}
+	sort.Slice(report.Parts, func(i, j int) bool {
+		if report.Parts[i].Damage != report.Parts[j].Damage {
+			return report.Parts[i].Damage > report.Parts[j].Damage
+		}
+		return report.Parts[i].PartID < report.Parts[j].PartID
+	})
+	for _, t := range traits {
+		if t.Attempts > 0 {
+			t.HitRate = float64(t.Hits) / float64(t.Attempts)
+		}
+		t.UseRate = float64(t.Uses) / float64(totalUses)
+		report.Traits = append(report.Traits, *t)
+	}
+	sort.Slice(report.Traits, func(i, j int) bool { return report.Traits[i].Trait < report.Traits[j].Trait })
+	for _, b := range broken {
+		report.BrokenParts = append(report.BrokenParts, *b)
+	}
+	sort.Slice(report.BrokenParts, func(i, j int) bool {
+		if report.BrokenParts[i].Broken != report.BrokenParts[j].Broken {
+			return report.BrokenParts[i].Broken > report.BrokenParts[j].Broken
+		}
+		return report.BrokenParts[i].PartID < report.BrokenParts[j].PartID
+	})
+	return report
+}
+
+// selectLoadouts はカンマ区切りのメダロットID（medarots.csv の id）から編成を作る。
+// 空なら medarots.csv で指定チームに属するメダロットを使う。
+func selectLoadouts(gameData *GameData, ids string, defaultTeam TeamID) ([]MedarotData, error) {
+	var loadouts []MedarotData
+	if strings.TrimSpace(ids) == "" {
+		for _, loadout := range gameData.Medarots {
+			if loadout.Team == defaultTeam {
+				loadouts = append(loadouts, loadout)
+			}
+		}
+		// リーダーを先頭にする
+		sort.SliceStable(loadouts, func(i, j int) bool { return loadouts[i].IsLeader && !loadouts[j].IsLeader })
+		return loadouts, nil
+	}
+	byID := make(map[string]MedarotData)
+	for _, loadout := range gameData.Medarots {
+		byID[loadout.ID] = loadout
+	}
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		loadout, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("medarots.csv に存在しないメダロットIDです: %s", id)
+		}
+		loadouts = append(loadouts, loadout)
+	}
+	return loadouts, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BattleLogKind はバトルログの種類
+type BattleLogKind string
+
+const (
+	LogKindSelect BattleLogKind = "select" // 行動を決めてチャージを開始した
+	LogKindAction BattleLogKind = "action" // 攻撃を実行した（命中・ミスを含む）
+	LogKindSystem BattleLogKind = "system" // 勝敗などのお知らせ
+)
+
+// BattleLogEntry はバトルログの1行。Result は攻撃の実行時だけ入る。
+type BattleLogEntry struct {
+	Tick   int
+	Kind   BattleLogKind
+	Team   TeamID
+	Text   string
+	Result *ActionResult `json:",omitempty"`
+}
+
+// IsDamage は実際にダメージを与えた攻撃のログかを返す
+func (e *BattleLogEntry) IsDamage() bool {
+	return e.Result != nil && e.Result.Hit && e.Result.Damage > 0
+}
+
+// FormatText はテキスト出力用に、経過秒とチームを付けた1行を返す
+func (e *BattleLogEntry) FormatText() string {
+	team := "----"
+	switch e.Team {
+	case Team1, Team2:
+		team = teamLabel(e.Team)
+	}
+	return T("log.entry", "time", fmt.Sprintf("%7.2f", float64(e.Tick)/SimulationTicksPerSecond), "team", team, "text", e.Text)
+}
+
+// battleLogFilter はログパネルの表示フィルタ
+type battleLogFilter struct {
+	damageOnly bool
+	myTeamOnly bool
+}
+
+// matches はエントリがフィルタ条件を満たすかを返す
+func (f battleLogFilter) matches(e *BattleLogEntry, playerTeam TeamID) bool {
+	if f.damageOnly && !e.IsDamage() {
+		return false
+	}
+	if f.myTeamOnly && e.Team != playerTeam {
+		return false
+	}
+	return true
+}
+
+// addBattleLog はバトルログに1行追加し、ログパネルにも反映する
+func (g *Game) addBattleLog(kind BattleLogKind, team TeamID, text string, result *ActionResult) {
+	entry := &BattleLogEntry{
+		Tick:   g.TickCount,
+		Kind:   kind,
+		Team:   team,
+		Text:   text,
+		Result: result,
+	}
+	g.battleLog = append(g.battleLog, entry)
+	if g.ui != nil && g.ui.battleLog != nil {
+		g.ui.battleLog.appendEntry(g, entry)
+	}
+}
+
+// ExportBattleLog はバトルログ全体を、拡張子 (.txt / .json) に応じた形式でファイルに書き出し、そのパスを返す
+func (g *Game) ExportBattleLog(ext string) (string, error) {
+	if err := os.MkdirAll(g.LogExportDir, 0755); err != nil {
+		return "", fmt.Errorf("ログ出力ディレクトリの作成に失敗: %w", err)
+	}
+	path := filepath.Join(g.LogExportDir, fmt.Sprintf("battle_log_%s%s", time.Now().Format("20060102_150405"), ext))
+
+	var data []byte
+	switch ext {
+	case ".json":
+		var err error
+		data, err = json.MarshalIndent(g.battleLog, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("バトルログの変換に失敗: %w", err)
+		}
+	case ".txt":
+		var sb strings.Builder
+		for _, entry := range g.battleLog {
+			sb.WriteString(entry.FormatText())
+			sb.WriteString("\n")
+		}
+		data = []byte(sb.String())
+	default:
+		return "", fmt.Errorf("未対応のログ形式です: %s", ext)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("バトルログの書き込みに失敗: %w", err)
+	}
+	return path, nil
+}
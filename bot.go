@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// 外部ボットとの通信プロトコル（1行に1つの JSON）
+//
+// エンジン → ボット: チームのメダロットが待機状態になるたびに、1回だけ BotRequest を送る。
+//
+//	{"Type":"select","Seq":3,"Tick":120,"Mode":"wait","MedarotID":"e1","Medarots":[...]}
+//
+// ボット → エンジン: 同じ Seq を付けて、使うパーツのスロットとターゲットのIDを返す。
+// Slot を空にすると、まだ行動せずに待機する。そのあと同じ Seq で改めて返事をすれば、そのときに行動する
+// （待機を返した依頼には制限時間を適用しない）。
+//
+//	{"Seq":3,"Slot":"r_arm","TargetID":"p2"}
+//
+// 返事は待たずにバトルを進め、ティックごとに届いているかを調べる。制限時間内に返事がない、
+// 不正な行動を返した、プロセスが終了した場合は、内蔵AIで代わりに決める。
+
+// defaultBotTimeout は外部ボットの返事を待つ既定の時間
+const defaultBotTimeout = 500 * time.Millisecond
+
+// BotRequest はボットに送る行動選択の依頼
+type BotRequest struct {
+	Type      string
+	Seq       int
+	Tick      int
+	Mode      BattleMode
+	MedarotID string
+	Medarots  []ObservedMedarot
+}
+
+// ObservedMedarot はボットから見えるメダロットの状態（画面に表示されている情報と同じ）
+type ObservedMedarot struct {
+	ID       string
+	Name     string
+	Team     TeamID
+	IsLeader bool
+	State    MedarotState
+	Gauge    float64
+	TargetID string
+	Parts    map[PartSlotKey]ObservedPart
+}
+
+// ObservedPart はボットから見えるパーツの状態
+type ObservedPart struct {
+	PartName string
+	Category PartCategory
+	Trait    Trait
+	Armor    int
+	MaxArmor int
+	Power    int
+	Accuracy int
+	Charge   int
+	Cooldown int
+	IsBroken bool
+}
+
+// BotReply はボットからの返事
+type BotReply struct {
+	Seq      int
+	Slot     PartSlotKey
+	TargetID string
+}
+
+// ExternalBotAI は別プロセスで動くボットに行動を決めさせる AIStrategy
+type ExternalBotAI struct {
+	Command  string
+	Timeout  time.Duration
+	Fallback AIStrategy // ボットが使えないときに代わりに行動を決めるAI
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	replies chan BotReply
+	seq     int
+	dead    bool
+	pending map[string]*botPending // メダロットIDごとの、返事を待っている依頼
+	answers map[int]BotReply       // 届いたがまだ使っていない返事（Seq ごと）
+}
+
+// botPending はメダロット1体の待機期間に送った依頼
+type botPending struct {
+	seq       int
+	idleSince int       // 依頼を送った待機期間が始まったティック。待機し直したら新しい依頼を送る
+	deadline  time.Time // この時刻を過ぎても返事が無ければ内蔵AIで決める
+	waiting   bool      // ボットが待機を返した。制限時間を適用せず、次の返事を待つ
+	timedOut  bool      // 時間切れになった。この待機期間は内蔵AIで決める
+}
+
+// NewExternalBotAI はコマンドラインで指定されたボットを起動する
+func NewExternalBotAI(command string, timeout time.Duration, fallback AIStrategy) (*ExternalBotAI, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("ボットのコマンドが空です")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("ボットの標準入力の準備に失敗: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("ボットの標準出力の準備に失敗: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("ボット '%s' の起動に失敗: %w", command, err)
+	}
+	bot := &ExternalBotAI{
+		Command:  command,
+		Timeout:  timeout,
+		Fallback: fallback,
+		cmd:      cmd,
+		stdin:    stdin,
+		replies:  make(chan BotReply, 16),
+		pending:  make(map[string]*botPending),
+		answers:  make(map[int]BotReply),
+	}
+	go bot.readReplies(stdout)
+	log.Printf("外部ボット '%s' を起動しました (pid %d)。", command, cmd.Process.Pid)
+	return bot, nil
+}
+
+// readReplies はボットの標準出力を1行ずつ読み、返事としてチャネルに送る
+func (b *ExternalBotAI) readReplies(stdout io.Reader) {
+	defer close(b.replies)
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var reply BotReply
+		if err := json.Unmarshal(scanner.Bytes(), &reply); err != nil {
+			log.Printf("外部ボットの返事を解析できません: %v (%s)", err, scanner.Text())
+			continue
+		}
+		b.replies <- reply
+	}
+}
+
+// SelectAction は ExternalBotAI の AIStrategy 実装。
+// 待機期間の最初に依頼を送るだけで返事は待たず、返事が届くまでは行動しない（バトルと画面は止めない）
+func (b *ExternalBotAI) SelectAction(game *Game, medarot *Medarot) (PartSlotKey, *Medarot, bool) {
+	b.receiveReplies()
+	if b.dead {
+		return b.Fallback.SelectAction(game, medarot)
+	}
+	idleSince := game.TickCount - medarot.IdleTicks
+	p := b.pending[medarot.ID]
+	if p == nil || p.idleSince != idleSince {
+		b.sendRequest(game, medarot, idleSince)
+		if b.dead {
+			return b.Fallback.SelectAction(game, medarot)
+		}
+		return "", nil, false
+	}
+	if p.timedOut {
+		return b.Fallback.SelectAction(game, medarot)
+	}
+	reply, ok := b.answers[p.seq]
+	if !ok {
+		if !p.waiting && time.Now().After(p.deadline) {
+			game.logger.Printf("%s: 外部ボットが %v 以内に返事をしなかったため内蔵AIで決めます。", medarot.Name, b.Timeout)
+			p.timedOut = true
+			return b.Fallback.SelectAction(game, medarot)
+		}
+		return "", nil, false
+	}
+	delete(b.answers, p.seq)
+	if reply.Slot == "" {
+		p.waiting = true
+		return "", nil, false
+	}
+	delete(b.pending, medarot.ID)
+	target, err := validateBotReply(game, medarot, reply)
+	if err != nil {
+		game.logger.Printf("%s: 外部ボットの行動が不正なため内蔵AIで決めます: %v", medarot.Name, err)
+		return b.Fallback.SelectAction(game, medarot)
+	}
+	return reply.Slot, target, true
+}
+
+// sendRequest は medarot の今の待機期間についての依頼をボットに送る。書き込めなければ以後は内蔵AIに任せる
+func (b *ExternalBotAI) sendRequest(game *Game, medarot *Medarot, idleSince int) {
+	b.seq++
+	data, err := json.Marshal(observeBattle(game, medarot, b.seq))
+	if err != nil {
+		log.Printf("外部ボットへの依頼の変換に失敗したため、以後は内蔵AIで行動します: %v", err)
+		b.dead = true
+		return
+	}
+	if _, err := b.stdin.Write(append(data, '\n')); err != nil {
+		log.Printf("外部ボットへの書き込みに失敗したため、以後は内蔵AIで行動します: %v", err)
+		b.dead = true
+		return
+	}
+	b.pending[medarot.ID] = &botPending{seq: b.seq, idleSince: idleSince, deadline: time.Now().Add(b.Timeout)}
+}
+
+// receiveReplies は届いている返事を待たずに読み出し、返事を待っている依頼への返事だけを残す
+func (b *ExternalBotAI) receiveReplies() {
+	for {
+		select {
+		case reply, ok := <-b.replies:
+			if !ok {
+				if !b.dead {
+					log.Printf("外部ボットが終了したため、以後は内蔵AIで行動します。")
+				}
+				b.dead = true
+				return
+			}
+			if b.isPending(reply.Seq) {
+				b.answers[reply.Seq] = reply
+			}
+			// それ以外（時間切れや待機し直した後の古い依頼への返事）は捨てる
+		default:
+			return
+		}
+	}
+}
+
+// isPending は seq の依頼がまだ返事を受け付けているかを返す
+func (b *ExternalBotAI) isPending(seq int) bool {
+	for _, p := range b.pending {
+		if p.seq == seq && !p.timedOut {
+			return true
+		}
+	}
+	return false
+}
+
+// Close はボットの標準入力を閉じて終了を促し、終わらなければ強制終了する
+func (b *ExternalBotAI) Close() {
+	b.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		b.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		b.cmd.Process.Kill()
+	}
+}
+
+// validateBotReply はボットが選んだパーツとターゲットが使えるものかを確かめ、ターゲットを返す
+func validateBotReply(game *Game, medarot *Medarot, reply BotReply) (*Medarot, error) {
+	part := medarot.GetPart(reply.Slot)
+	if part == nil || part.IsBroken || part.Category == CategoryNone {
+		return nil, fmt.Errorf("スロット '%s' のパーツは使えません", reply.Slot)
+	}
+	for _, candidate := range getTargetCandidates(game, medarot) {
+		if candidate.ID == reply.TargetID {
+			return candidate, nil
+		}
+	}
+	return nil, fmt.Errorf("'%s' は攻撃対象にできません", reply.TargetID)
+}
+
+// observeBattle はボットに送る行動選択の依頼を作る
+func observeBattle(game *Game, medarot *Medarot, seq int) BotRequest {
+	req := BotRequest{
+		Type:      "select",
+		Seq:       seq,
+		Tick:      game.TickCount,
+		Mode:      game.BattleMode,
+		MedarotID: medarot.ID,
+	}
+	for _, m := range game.Medarots {
+		observed := ObservedMedarot{
+			ID:       m.ID,
+			Name:     m.Name,
+			Team:     m.Team,
+			IsLeader: m.IsLeader,
+			State:    m.State,
+			Gauge:    m.Gauge,
+			Parts:    make(map[PartSlotKey]ObservedPart),
+		}
+		if m.TargetedMedarot != nil {
+			observed.TargetID = m.TargetedMedarot.ID
+		}
+		for slot, part := range m.Parts {
+			observed.Parts[slot] = ObservedPart{
+				PartName: part.PartName,
+				Category: part.Category,
+				Trait:    part.Trait,
+				Armor:    part.Armor,
+				MaxArmor: part.MaxArmor,
+				Power:    part.Power,
+				Accuracy: part.Accuracy,
+				Charge:   part.Charge,
+				Cooldown: part.Cooldown,
+				IsBroken: part.IsBroken,
+			}
+		}
+		req.Medarots = append(req.Medarots, observed)
+	}
+	return req
+}
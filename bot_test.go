@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// botInput はボットの標準入力の代わりに、送られた依頼を溜めておく
+type botInput struct{ bytes.Buffer }
+
+func (*botInput) Close() error { return nil }
+
+// requests は送られた依頼を順に返す
+func (in *botInput) requests(t *testing.T) []BotRequest {
+	t.Helper()
+	var reqs []BotRequest
+	dec := json.NewDecoder(bytes.NewReader(in.Bytes()))
+	for dec.More() {
+		var req BotRequest
+		if err := dec.Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// newTestBot はプロセスを起動せず、依頼を in に書き、返事を replies から受け取る ExternalBotAI を作る
+func newTestBot(timeout time.Duration) (*ExternalBotAI, *botInput, chan BotReply) {
+	in := &botInput{}
+	replies := make(chan BotReply, 16)
+	bot := &ExternalBotAI{
+		Command:  "test",
+		Timeout:  timeout,
+		Fallback: NewScoringAI(aiProfiles[AINormal]),
+		stdin:    in,
+		replies:  replies,
+		pending:  make(map[string]*botPending),
+		answers:  make(map[int]BotReply),
+	}
+	return bot, in, replies
+}
+
+// TestExternalBotOneRequestPerIdlePeriod は、返事を待たずに戻り、同じ待機期間には依頼を1回しか送らないことを確かめる。
+// 待機を返した後も依頼を送り直さず、同じ Seq の次の返事で行動する
+func TestExternalBotOneRequestPerIdlePeriod(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestBattle(t, data, string(AINormal))
+	m := g.Medarots[0]
+	bot, in, replies := newTestBot(time.Hour)
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if _, _, ok := bot.SelectAction(g, m); ok {
+			t.Fatal("返事が無いのに行動しました")
+		}
+		g.TickCount++
+		m.IdleTicks++
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("返事を待って止まりました (%v)", elapsed)
+	}
+	reqs := in.requests(t)
+	if len(reqs) != 1 {
+		t.Fatalf("依頼を %d 回送りました (1回のはず)", len(reqs))
+	}
+
+	replies <- BotReply{Seq: reqs[0].Seq}
+	for i := 0; i < 10; i++ {
+		if _, _, ok := bot.SelectAction(g, m); ok {
+			t.Fatal("待機を返したのに行動しました")
+		}
+	}
+	if n := len(in.requests(t)); n != 1 {
+		t.Fatalf("待機を返した後に依頼を送り直しました (%d回)", n)
+	}
+
+	target := getTargetCandidates(g, m)[0]
+	slot := PartSlotKey("")
+	for _, s := range []PartSlotKey{PartSlotHead, PartSlotRightArm, PartSlotLeftArm} {
+		if p := m.GetPart(s); p != nil && !p.IsBroken && p.Category != CategoryNone {
+			slot = s
+			break
+		}
+	}
+	replies <- BotReply{Seq: reqs[0].Seq, Slot: slot, TargetID: target.ID}
+	gotSlot, gotTarget, ok := bot.SelectAction(g, m)
+	if !ok || gotSlot != slot || gotTarget != target {
+		t.Errorf("ボットの行動 (%s, %s) になりませんでした: %s, %v", slot, target.ID, gotSlot, ok)
+	}
+
+	// 次の待機期間には新しい依頼を送る
+	m.IdleTicks = 0
+	bot.SelectAction(g, m)
+	if n := len(in.requests(t)); n != 2 {
+		t.Errorf("新しい待機期間に依頼を送りませんでした (%d回)", n)
+	}
+}
+
+// TestExternalBotFallsBackAfterDeadline は制限時間を過ぎたら内蔵AIで行動し、遅れて届いた返事は捨てることを確かめる
+func TestExternalBotFallsBackAfterDeadline(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestBattle(t, data, string(AINormal))
+	m := g.Medarots[0]
+	bot, in, replies := newTestBot(0)
+
+	bot.SelectAction(g, m)
+	time.Sleep(time.Millisecond)
+	_, _, ok := bot.SelectAction(g, m)
+	if !ok {
+		t.Fatal("時間切れの後に内蔵AIで行動しませんでした")
+	}
+	replies <- BotReply{Seq: in.requests(t)[0].Seq, Slot: PartSlotHead, TargetID: "late"}
+	bot.SelectAction(g, m)
+	if len(bot.answers) != 0 {
+		t.Error("時間切れの依頼への返事を残しました")
+	}
+}
@@ -1,14 +1,174 @@
 package main
 
 import (
-	"github.com/ebitenui/ebitenui/widget"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"image/color"
+	"os"
+	"strconv"
+	"strings"
 )
 
-func LoadConfig() Config {
-	screenWidth := 1280
-	screenHeight := 720
+// HexColor は設定ファイルで "#RRGGBB" または "#RRGGBBAA" と書ける色
+type HexColor color.RGBA
 
+// rgb は不透明な HexColor を作る
+func rgb(r, g, b uint8) HexColor {
+	return HexColor{R: r, G: g, B: b, A: 255}
+}
+
+func (c HexColor) RGBA() (r, g, b, a uint32) {
+	return color.RGBA(c).RGBA()
+}
+
+func (c HexColor) String() string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A)
+}
+
+func (c HexColor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *HexColor) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("色は \"#RRGGBB\" の形の文字列で書いてください")
+	}
+	parsed, err := ParseHexColor(s)
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// ParseHexColor は "#RRGGBB" または "#RRGGBBAA"（先頭の # は省略可）を色にする
+func ParseHexColor(s string) (HexColor, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return HexColor{}, fmt.Errorf("色 '%s' は #RRGGBB か #RRGGBBAA にしてください", s)
+	}
+	if len(hex) == 6 {
+		hex += "FF"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return HexColor{}, fmt.Errorf("色 '%s' は16進数ではありません", s)
+	}
+	return HexColor{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// defaultConfigPath は既定で読む設定ファイル
+const defaultConfigPath = "config.json"
+
+// LoadConfig は組み込みの既定値 (DefaultConfig) に設定ファイル (JSON) の値を重ねる。
+// ファイルには変えたい項目だけを書けばよい。ファイルが無ければ既定値をそのまま使う。
+// 画面の大きさから決まる値（戦場の位置や高さ、ログの高さ）は、ファイルで書かなければ読み込んだ後の画面の大きさから計算する。
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return DefaultConfig(), fmt.Errorf("設定ファイルの読み込みに失敗: %w", err)
+	}
+	config := defaultConfigWithoutLayout()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return config, fmt.Errorf("%s の解析に失敗: %w", path, err)
+	}
+	config.fillLayoutDefaults()
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("%s の値が不正です:\n%w", path, err)
+	}
+	return config, nil
+}
+
+// Validate は設定の値が意味のある範囲にあるか調べ、問題をまとめて返す
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, name string, value any, want string) {
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s = %v: %s", name, value, want))
+		}
+	}
+	b := &c.Balance
+	check(b.Time.PropulsionEffectRate >= 0, "Balance.Time.PropulsionEffectRate", b.Time.PropulsionEffectRate, "0以上にしてください")
+	check(b.Time.GameSpeedMultiplier > 0, "Balance.Time.GameSpeedMultiplier", b.Time.GameSpeedMultiplier, "0より大きくしてください")
+	check(b.Hit.BaseChance >= 0 && b.Hit.BaseChance <= 100, "Balance.Hit.BaseChance", b.Hit.BaseChance, "0〜100にしてください")
+	for name, v := range map[string]int{
+		"Balance.Hit.TraitAimBonus":      b.Hit.TraitAimBonus,
+		"Balance.Hit.TraitStrikeBonus":   b.Hit.TraitStrikeBonus,
+		"Balance.Hit.TraitBerserkDebuff": b.Hit.TraitBerserkDebuff,
+	} {
+		check(v >= -100 && v <= 100, name, v, "-100〜100にしてください")
+	}
+	check(b.Damage.CriticalMultiplier >= 1, "Balance.Damage.CriticalMultiplier", b.Damage.CriticalMultiplier, "1以上にしてください")
+	check(b.Damage.MedalSkillFactor >= 0, "Balance.Damage.MedalSkillFactor", b.Damage.MedalSkillFactor, "0以上にしてください")
+
+	u := &c.UI
+	check(u.Screen.Width > 0, "UI.Screen.Width", u.Screen.Width, "0より大きくしてください")
+	check(u.Screen.Height > 0, "UI.Screen.Height", u.Screen.Height, "0より大きくしてください")
+	for name, v := range map[string]float32{
+		"UI.Battlefield.Height":            u.Battlefield.Height,
+		"UI.Battlefield.IconRadius":        u.Battlefield.IconRadius,
+		"UI.Battlefield.HomeMarkerRadius":  u.Battlefield.HomeMarkerRadius,
+		"UI.Battlefield.LineWidth":         u.Battlefield.LineWidth,
+		"UI.InfoPanel.BlockWidth":          u.InfoPanel.BlockWidth,
+		"UI.InfoPanel.BlockHeight":         u.InfoPanel.BlockHeight,
+		"UI.InfoPanel.PartHPGaugeWidth":    u.InfoPanel.PartHPGaugeWidth,
+		"UI.InfoPanel.PartHPGaugeHeight":   u.InfoPanel.PartHPGaugeHeight,
+		"UI.ActionModal.ButtonWidth":       u.ActionModal.ButtonWidth,
+		"UI.ActionModal.ButtonHeight":      u.ActionModal.ButtonHeight,
+		"UI.BattleLog.Height":              u.BattleLog.Height,
+	} {
+		check(v > 0, name, v, "0より大きくしてください")
+	}
+	check(u.InfoPanel.Padding >= 0, "UI.InfoPanel.Padding", u.InfoPanel.Padding, "0以上にしてください")
+	check(u.ActionModal.ButtonSpacing >= 0, "UI.ActionModal.ButtonSpacing", u.ActionModal.ButtonSpacing, "0以上にしてください")
+	_, known := catalogs[u.Language]
+	check(known, "UI.Language", u.Language, strings.Join(Languages(), ", ")+" のいずれかにしてください")
+	return errors.Join(problems...)
+}
+
+// DefaultConfig は組み込みの既定の設定
+func DefaultConfig() Config {
+	config := defaultConfigWithoutLayout()
+	config.fillLayoutDefaults()
+	return config
+}
+
+// fillLayoutDefaults は画面の大きさから決まる値のうち、まだ決まっていない (0 の) ものを UI.Screen から計算して埋める
+func (c *Config) fillLayoutDefaults() {
+	screenWidth := float32(c.UI.Screen.Width)
+	screenHeight := float32(c.UI.Screen.Height)
+	fill := func(v *float32, value float32) {
+		if *v == 0 {
+			*v = value
+		}
+	}
+	bf := &c.UI.Battlefield
+	fill(&bf.Height, screenHeight*0.5)
+	fill(&bf.Team1HomeX, screenWidth*0.1)
+	fill(&bf.Team2HomeX, screenWidth*0.9)
+	fill(&bf.Team1ExecutionLineX, screenWidth*0.4)
+	fill(&bf.Team2ExecutionLineX, screenWidth*0.6)
+	fill(&bf.MedarotVerticalSpacing, bf.Height/float32(PlayersPerTeam+1))
+	fill(&c.UI.BattleLog.Height, screenHeight*0.3)
+}
+
+// defaultConfigWithoutLayout は画面の大きさから決まる値を 0 のままにした既定の設定。
+// 設定ファイルで画面の大きさだけを変えたときも、それに合わせて配置を計算できるようにする。
+func defaultConfigWithoutLayout() Config {
 	return Config{
 		Balance: BalanceConfig{
 			Time: struct {
@@ -42,15 +202,15 @@ func LoadConfig() Config {
 			},
 		},
 		UI: UIConfig{
+			Language: defaultLanguage,
 			Screen: struct {
 				Width  int
 				Height int
 			}{
-				Width:  screenWidth,
-				Height: screenHeight,
+				Width:  1280,
+				Height: 720,
 			},
 			Battlefield: struct {
-				Rect                   *widget.Container
 				Height                 float32
 				Team1HomeX             float32
 				Team2HomeX             float32
@@ -61,15 +221,9 @@ func LoadConfig() Config {
 				LineWidth              float32
 				MedarotVerticalSpacing float32
 			}{
-				Height:                 float32(screenHeight) * 0.5,
-				Team1HomeX:             float32(screenWidth) * 0.1,
-				Team2HomeX:             float32(screenWidth) * 0.9,
-				Team1ExecutionLineX:    float32(screenWidth) * 0.4,
-				Team2ExecutionLineX:    float32(screenWidth) * 0.6,
-				IconRadius:             12,
-				HomeMarkerRadius:       15,
-				LineWidth:              2,
-				MedarotVerticalSpacing: float32(screenHeight) * 0.5 / float32(PlayersPerTeam+1),
+				IconRadius:       12,
+				HomeMarkerRadius: 15,
+				LineWidth:        2,
 			},
 			InfoPanel: struct {
 				Padding           int
@@ -94,31 +248,31 @@ func LoadConfig() Config {
 				ButtonSpacing: 10,
 			},
 			Colors: struct {
-				White      color.Color
-				Red        color.Color
-				Blue       color.Color
-				Yellow     color.Color
-				Gray       color.Color
-				Team1      color.Color
-				Team2      color.Color
-				Leader     color.Color
-				Broken     color.Color
-				HP         color.Color
-				HPCritical color.Color
-				Background color.Color
+				White      HexColor
+				Red        HexColor
+				Blue       HexColor
+				Yellow     HexColor
+				Gray       HexColor
+				Team1      HexColor
+				Team2      HexColor
+				Leader     HexColor
+				Broken     HexColor
+				HP         HexColor
+				HPCritical HexColor
+				Background HexColor
 			}{
-				White:      color.White,
-				Red:        color.RGBA{R: 255, G: 100, B: 100, A: 255},
-				Blue:       color.RGBA{R: 100, G: 100, B: 255, A: 255},
-				Yellow:     color.RGBA{R: 255, G: 255, B: 100, A: 255},
-				Gray:       color.RGBA{R: 150, G: 150, B: 150, A: 255},
-				Team1:      color.RGBA{R: 50, G: 150, B: 255, A: 255},
-				Team2:      color.RGBA{R: 255, G: 50, B: 50, A: 255},
-				Leader:     color.RGBA{R: 255, G: 215, B: 0, A: 255},
-				Broken:     color.RGBA{R: 80, G: 80, B: 80, A: 255},
-				HP:         color.RGBA{R: 0, G: 200, B: 100, A: 255},
-				HPCritical: color.RGBA{R: 255, G: 100, B: 0, A: 255},
-				Background: color.RGBA{R: 30, G: 30, B: 40, A: 255},
+				White:      rgb(255, 255, 255),
+				Red:        rgb(255, 100, 100),
+				Blue:       rgb(100, 100, 255),
+				Yellow:     rgb(255, 255, 100),
+				Gray:       rgb(150, 150, 150),
+				Team1:      rgb(50, 150, 255),
+				Team2:      rgb(255, 50, 50),
+				Leader:     rgb(255, 215, 0),
+				Broken:     rgb(80, 80, 80),
+				HP:         rgb(0, 200, 100),
+				HPCritical: rgb(255, 100, 0),
+				Background: rgb(30, 30, 40),
 			},
 		},
 	}
@@ -1,161 +1,493 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
 
-// ... (parseInt, parseBool, LoadMedalsは変更なし) ...
-func parseInt(s string, defaultValue int) int {
-	s = strings.TrimSpace(s)
+// noneValue は「その値を持たない」ことを表す値（脚パーツの威力など）。JSON / YAML では null でもよい
+const noneValue = "NONE"
+
+// dataSchemaVersion は今のデータファイルの形式（スキーマ）の版。ファイルの先頭に書き、古い版は medadata migrate で上げる。
+//
+//	1: 版の記載なし。CSV は列の位置で読み（ヘッダーの名前は見ない）、数値でない値は黙って既定値にしていた
+//	2: CSV はヘッダーの列名で読み、値の無い数値は NONE と書く。name_en などの別の言語の名前の列と、parts の trait_params（入れ子の値）を追加
+const dataSchemaVersion = 2
+
+// schemaVersionKey は版を書くキー。CSV では先頭のコメント行 "# schema_version: 2"、JSON / YAML では最上位のキー
+const schemaVersionKey = "schema_version"
+
+// dataType は列の値の種類。JSON / YAML にはこの種類で書き出す（"007" のような ID を見た目で数値にしないよう、値からは推測しない）
+type dataType int
+
+const (
+	dataString dataType = iota // 文字列
+	dataInt                    // 整数。NONE は JSON / YAML では null
+	dataBool                   // true / false
+	dataNested                 // 入れ子の値（マッピングやリスト）。CSV のセルには JSON で書く
+)
+
+// dataColumn はデータファイルの列（JSON / YAML ではキー）の定義。Required の列が無ければエラーにする
+type dataColumn struct {
+	Name     string
+	Type     dataType
+	Required bool
+	Since    int // この列が加わったスキーマの版（0 なら最初から）。版1の CSV はこれより前の列を位置で読む
+}
+
+// dataRecord はデータファイルの1行（JSON / YAML では配列の1要素）。
+// 値は列名で引き、値の問題は where が返す位置（ファイル名:行:列 など）付きで errs に溜める
+type dataRecord struct {
+	values map[string]string
+	where  func(column string) string
+	errs   *[]error
+}
+
+// errorf は列の位置を付けて問題を記録する
+func (r *dataRecord) errorf(column, format string, args ...any) {
+	*r.errs = append(*r.errs, fmt.Errorf("%s: 列 %s: %s", r.where(column), column, fmt.Sprintf(format, args...)))
+}
+
+// str は列の値を返す。任意の列が無ければ空文字を返す
+func (r *dataRecord) str(column string) string {
+	return strings.TrimSpace(r.values[column])
+}
+
+// required は空でない値を返す。空なら問題を記録する
+func (r *dataRecord) required(column string) string {
+	s := r.str(column)
 	if s == "" {
-		return defaultValue
+		r.errorf(column, "値が空です")
+	}
+	return s
+}
+
+// int は整数の値を返す。空・NONE・数値でない値は問題として記録する
+func (r *dataRecord) int(column string) int {
+	s := r.required(column)
+	if s == "" {
+		return 0
 	}
 	i, err := strconv.Atoi(s)
 	if err != nil {
-		return defaultValue
+		r.errorf(column, "'%s' は整数ではありません", s)
+		return 0
 	}
 	return i
 }
 
-func parseBool(s string) bool {
-	return strings.ToLower(strings.TrimSpace(s)) == "true"
+// intOrNone は整数の値を返す。NONE なら noneDefault を返す（空や数値でない値は問題として記録する）
+func (r *dataRecord) intOrNone(column string, noneDefault int) int {
+	if r.str(column) == noneValue {
+		return noneDefault
+	}
+	return r.int(column)
 }
 
-func LoadMedals(filePath string) ([]Medal, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
+// bool は true / false の値を返す
+func (r *dataRecord) bool(column string) bool {
+	switch s := strings.ToLower(r.required(column)); s {
+	case "true":
+		return true
+	case "false", "":
+		return false
+	default:
+		r.errorf(column, "'%s' は true か false にしてください", r.str(column))
+		return false
 	}
-	defer file.Close()
-	reader := csv.NewReader(file)
-	reader.Read() // Skip header
+}
 
-	var medals []Medal
-	for {
-		// medals.csv の列数が少ないので、lenチェックは不要
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
+// oneOf は allowed のいずれかの値を返す
+func (r *dataRecord) oneOf(column string, allowed ...string) string {
+	s := r.required(column)
+	if s == "" {
+		return ""
+	}
+	for _, a := range allowed {
+		if s == a {
+			return s
 		}
-		if err != nil {
-			continue
+	}
+	r.errorf(column, "'%s' は %s のいずれかにしてください", s, strings.Join(allowed, ", "))
+	return s
+}
+
+// nested は入れ子の列の値を、JSON を読んだときと同じ形 (map[string]any, []any, json.Number など) で返す。
+// 列が無い・空・NONE なら nil を返す
+func (r *dataRecord) nested(column string) any {
+	s := r.str(column)
+	if s == "" || s == noneValue {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		r.errorf(column, "'%s' は JSON として読めません: %v", s, err)
+		return nil
+	}
+	return v
+}
+
+// readDataFile は src の name を拡張子に合った形式 (.csv, .json, .yaml / .yml) で読み、1行ごとに row を呼ぶ。
+// 形式ごとの問題（未知の列など）・重複したID・不正な値は、すべてまとめてエラーとして返す。
+func readDataFile(src dataSource, name string, columns []dataColumn, row func(r *dataRecord)) error {
+	var problems []error
+	records, version, err := readRecords(src, name, columns, &problems)
+	if err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(src.path(name), version); err != nil {
+		return err
+	}
+
+	firstSeen := make(map[string]string)
+	for _, record := range records {
+		// id 列はどのファイルでも重複してはいけない（parts は後の行で上書きされてしまう）
+		if id := record.str("id"); id != "" {
+			if first, dup := firstSeen[id]; dup {
+				record.errorf("id", "'%s' が重複しています (%s と同じ)", id, first)
+			} else {
+				firstSeen[id] = record.where("id")
+			}
 		}
-		// medals.csv の列構造に合わせて修正
-		medals = append(medals, Medal{
-			ID:   record[0],
-			Name: record[1],
-			// skill_shoot, skill_fightを考慮して、ここでは単純にSkillLevelを固定値にするか、
-			// またはCSVに合わせてMedal構造体自体を修正する必要があります。
-			// 今回は skill_fight を代表値として使います。
-			SkillLevel: parseInt(record[6], 1), // "skill_fight" はインデックス6
-		})
+		row(record)
 	}
-	return medals, nil
+	return errors.Join(problems...)
 }
 
-// [FIXED] LoadParts のインデックスをCSVの列に完全に合わせる
-func LoadParts(filePath string) (map[string]*Part, error) {
-	file, err := os.Open(filePath)
+// checkSchemaVersion は読んだファイルが今のスキーマの版かを調べる
+func checkSchemaVersion(filePath string, version int) error {
+	switch {
+	case version < dataSchemaVersion:
+		return fmt.Errorf("%s: データ形式が古い版 (v%d) です。medarot-ebiten medadata migrate -dir %s で v%d に更新してください",
+			filePath, version, filepath.Dir(filePath), dataSchemaVersion)
+	case version > dataSchemaVersion:
+		return fmt.Errorf("%s: このゲームより新しいデータ形式 (v%d) です。読めるのは v%d までです", filePath, version, dataSchemaVersion)
+	}
+	return nil
+}
+
+// readRecords はデータファイルを拡張子に合った形式で読み、行ごとの値とファイルのスキーマの版を返す。
+// 版は確かめない（古い版も読めるのは medadata migrate のため）ので、読み込みでは readDataFile を使う
+func readRecords(src dataSource, name string, columns []dataColumn, problems *[]error) ([]*dataRecord, int, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return readCSVRecords(src, name, columns, problems)
+	case ".json":
+		return readJSONRecords(src, name, columns, problems)
+	case ".yaml", ".yml":
+		return readYAMLRecords(src, name, columns, problems)
+	}
+	return nil, 0, fmt.Errorf("%s: 未対応の形式です (%s のいずれか)", src.path(name), strings.Join(dataFileExtensions, ", "))
+}
+
+// csvSchemaVersion は CSV の先頭のコメント行 "# schema_version: N" から版を読む。コメント行が無ければ版1
+func csvSchemaVersion(filePath string, data []byte) (int, error) {
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	line := strings.TrimSpace(string(first))
+	if !strings.HasPrefix(line, "#") {
+		return 1, nil
+	}
+	key, value, ok := strings.Cut(strings.TrimSpace(line[1:]), ":")
+	version, err := strconv.Atoi(strings.TrimSpace(value))
+	if !ok || strings.TrimSpace(key) != schemaVersionKey || err != nil || version < 1 {
+		return 0, fmt.Errorf("%s:1: 先頭のコメント行は \"# %s: %d\" の形にしてください", filePath, schemaVersionKey, dataSchemaVersion)
+	}
+	return version, nil
+}
+
+// readCSVRecords はヘッダーの列名で列を対応付けてCSVを読む（版1のファイルは列の位置で対応付ける）。
+// 足りない必須列・未知の列・重複した列があれば行を読まずに problems に記録し、列数の合わない行は報告して読み飛ばす。
+// # で始まる行はコメントとして読み飛ばす。
+func readCSVRecords(src dataSource, name string, columns []dataColumn, problems *[]error) ([]*dataRecord, int, error) {
+	filePath := src.path(name)
+	data, err := fs.ReadFile(src.fsys, name)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	defer file.Close()
-	reader := csv.NewReader(file)
-	reader.Read() // Skip header
+	data = bytes.TrimPrefix(data, []byte("\ufeff")) // Excel が付ける BOM を除く
+	version, err := csvSchemaVersion(filePath, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
 
-	partsMap := make(map[string]*Part)
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, version, fmt.Errorf("%s: ヘッダー行がありません", filePath)
+	}
+	if err != nil {
+		return nil, version, fmt.Errorf("%s: %w", filePath, err)
+	}
+	headerLine, _ := reader.FieldPos(0)
+	known := make(map[string]bool)
+	var legacy []string // 版1の CSV は、この順に列の位置で読む（ヘッダーの名前は見ない）
+	for _, c := range columns {
+		known[c.Name] = true
+		if version == 1 && c.Since <= 1 {
+			legacy = append(legacy, c.Name)
+		}
+	}
+	index := make(map[string]int)
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if i < len(legacy) {
+			column = legacy[i]
 		}
-		if err != nil || len(record) < 14 { // 列数は14
+		if !known[column] {
+			*problems = append(*problems, fmt.Errorf("%s:%d:%d: 未知の列 '%s'", filePath, headerLine, i+1, column))
 			continue
 		}
-		// 正しいインデックスでArmorを読み込む
-		armor := parseInt(record[6], 1)
-		part := &Part{
-			ID:       record[0],
-			PartName: record[1],
-			Type:     PartType(record[2]),
-			Category: PartCategory(record[3]),
-			Trait:    Trait(record[4]),
-			// weapon_type (record[5]) は現在Part構造体にないので読み飛ばす
-			Armor:      armor,
-			MaxArmor:   armor,
-			Power:      parseInt(record[7], 0),  // power はインデックス7
-			Charge:     parseInt(record[8], 1),  // charge はインデックス8
-			Cooldown:   parseInt(record[9], 1),  // cooldown はインデックス9
-			Defense:    parseInt(record[10], 0), // defense はインデックス10
-			Accuracy:   parseInt(record[11], 0), // accuracy はインデックス11
-			Mobility:   parseInt(record[12], 0), // mobility はインデックス12
-			Propulsion: parseInt(record[13], 0), // propulsion はインデックス13
-			IsBroken:   false,
+		if _, dup := index[column]; dup {
+			*problems = append(*problems, fmt.Errorf("%s:%d:%d: 列 '%s' が重複しています", filePath, headerLine, i+1, column))
+			continue
 		}
-		partsMap[part.ID] = part
+		index[column] = i
 	}
-	return partsMap, nil
-}
-
-// ... (LoadMedarotLoadouts, LoadAllGameDataは変更なし) ...
-func LoadMedarotLoadouts(filePath string) ([]MedarotData, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
+	for _, c := range columns {
+		if _, ok := index[c.Name]; c.Required && !ok {
+			*problems = append(*problems, fmt.Errorf("%s:%d: 必須の列 '%s' がありません", filePath, headerLine, c.Name))
+		}
+	}
+	if len(*problems) > 0 {
+		return nil, version, nil // 列の対応が取れないので行は読まない
 	}
-	defer file.Close()
-	reader := csv.NewReader(file)
-	reader.Read() // Skip header
 
-	var medarots []MedarotData
+	var records []*dataRecord
 	for {
-		record, err := reader.Read()
+		fields, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
-		if err != nil || len(record) < 10 {
+		if err != nil {
+			// 列数の合わない行なども、行番号付きで報告して次の行へ進む
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+				err = fmt.Errorf("%s:%d: 列の数 (%d) がヘッダー (%d) と合いません", filePath, parseErr.StartLine, len(fields), len(header))
+			} else {
+				err = fmt.Errorf("%s: %w", filePath, err)
+			}
+			*problems = append(*problems, err)
 			continue
 		}
-		medarot := MedarotData{
-			ID:         record[0],
-			Name:       record[1],
-			Team:       TeamID(parseInt(record[2], 0)),
-			IsLeader:   parseBool(record[3]),
-			DrawIndex:  parseInt(record[4], 0),
-			MedalID:    record[5],
-			HeadID:     record[6],
-			RightArmID: record[7],
-			LeftArmID:  record[8],
-			LegsID:     record[9],
+		line, _ := reader.FieldPos(0)
+		values := make(map[string]string, len(index))
+		for column, i := range index {
+			values[column] = fields[i]
 		}
-		medarots = append(medarots, medarot)
+		records = append(records, &dataRecord{
+			values: values,
+			where: func(column string) string {
+				if i, ok := index[column]; ok {
+					return fmt.Sprintf("%s:%d:%d", filePath, line, i+1)
+				}
+				return fmt.Sprintf("%s:%d", filePath, line)
+			},
+			errs: problems,
+		})
 	}
-	return medarots, nil
+	return records, version, nil
 }
 
-func LoadAllGameData() (*GameData, error) {
-	gameData := &GameData{}
-	var err error
+// medalColumns は medals.csv の列。今は skill_fight を代表値として SkillLevel に使う。name_en などは別の言語の名前
+var medalColumns = append([]dataColumn{
+	{Name: "id", Required: true},
+	{Name: "name_jp", Required: true},
+	{Name: "personality_jp"},
+	{Name: "medaforce_jp"},
+	{Name: "attribute_jp"},
+	{Name: "skill_shoot", Type: dataInt},
+	{Name: "skill_fight", Type: dataInt, Required: true},
+	{Name: "skill_scan", Type: dataInt},
+	{Name: "skill_support", Type: dataInt},
+}, localizedColumns("name")...)
 
-	gameData.Medals, err = LoadMedals("data/medals.csv")
-	if err != nil {
-		return nil, fmt.Errorf("medals.csvの読み込みに失敗: %w", err)
+func LoadMedals(src dataSource, name string) ([]Medal, error) {
+	var medals []Medal
+	err := readDataFile(src, name, medalColumns, func(r *dataRecord) {
+		medals = append(medals, Medal{
+			ID:         r.required("id"),
+			Name:       r.required("name_jp"),
+			Names:      r.localizedNames("name"),
+			SkillLevel: r.int("skill_fight"),
+		})
+	})
+	return medals, err
+}
+
+// partColumns は parts.csv の列。weapon_type は今は Part 構造体に無いので読み飛ばす。part_name_en などは別の言語の名前。
+// trait_params は特性の効果をパーツごとに変える入れ子の値 ({"hit_bonus": 30} など。TraitParams を参照)
+var partColumns = append([]dataColumn{
+	{Name: "id", Required: true},
+	{Name: "part_name", Required: true},
+	{Name: "part_type", Required: true},
+	{Name: "action_category", Required: true},
+	{Name: "action_trait", Required: true},
+	{Name: "weapon_type"},
+	{Name: "armor", Type: dataInt, Required: true},
+	{Name: "power", Type: dataInt, Required: true},
+	{Name: "charge", Type: dataInt, Required: true},
+	{Name: "cooldown", Type: dataInt, Required: true},
+	{Name: "defense", Type: dataInt, Required: true},
+	{Name: "accuracy", Type: dataInt, Required: true},
+	{Name: "mobility", Type: dataInt, Required: true},
+	{Name: "propulsion", Type: dataInt, Required: true},
+	{Name: "trait_params", Type: dataNested, Since: 2},
+}, localizedColumns("part_name")...)
+
+// traitParams は trait_params 列のマッピングを読む。未知のキーや整数でない値は問題として記録する
+func (r *dataRecord) traitParams(column string) TraitParams {
+	var params TraitParams
+	v := r.nested(column)
+	if v == nil {
+		return params
 	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		r.errorf(column, "{\"hit_bonus\": 30} のようなマッピングにしてください")
+		return params
+	}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		value := m[key]
+		n, ok := value.(json.Number)
+		i, err := n.Int64()
+		if !ok || err != nil {
+			r.errorf(column, "%s の値 %v は整数にしてください", key, value)
+			continue
+		}
+		switch key {
+		case "hit_bonus":
+			bonus := int(i)
+			params.HitBonus = &bonus
+		default:
+			r.errorf(column, "未知のキー '%s' (hit_bonus が使えます)", key)
+		}
+	}
+	return params
+}
 
-	gameData.AllParts, err = LoadParts("data/parts.csv")
-	if err != nil {
-		return nil, fmt.Errorf("parts.csvの読み込みに失敗: %w", err)
+// LoadParts は parts.csv を読む。攻撃しないパーツの威力などは NONE と書き、以前と同じ既定値として扱う
+func LoadParts(src dataSource, name string) (map[string]*Part, error) {
+	partsMap := make(map[string]*Part)
+	err := readDataFile(src, name, partColumns, func(r *dataRecord) {
+		armor := r.int("armor")
+		part := &Part{
+			ID:       r.required("id"),
+			PartName: r.required("part_name"),
+			Names:    r.localizedNames("part_name"),
+			Type:     PartType(r.oneOf("part_type", string(PartTypeHead), string(PartTypeRArm), string(PartTypeLArm), string(PartTypeLegs))),
+			Category: PartCategory(r.oneOf("action_category", string(CategoryShoot), string(CategoryMelee), string(CategoryNone))),
+			Trait: Trait(r.oneOf("action_trait", string(TraitAim), string(TraitStrike), string(TraitBerserk),
+				string(TraitNormal), string(TraitNone))),
+			Armor:       armor,
+			MaxArmor:    armor,
+			Power:       r.intOrNone("power", 0),
+			Charge:      r.intOrNone("charge", 1),
+			Cooldown:    r.intOrNone("cooldown", 1),
+			Defense:     r.intOrNone("defense", 0),
+			Accuracy:    r.intOrNone("accuracy", 0),
+			Mobility:    r.intOrNone("mobility", 0),
+			Propulsion:  r.intOrNone("propulsion", 0),
+			TraitParams: r.traitParams("trait_params"),
+			IsBroken:    false,
+		}
+		partsMap[part.ID] = part
+	})
+	return partsMap, err
+}
+
+// medarotColumns は medarots.csv の列。name_en などは別の言語の名前
+var medarotColumns = append([]dataColumn{
+	{Name: "id", Required: true},
+	{Name: "name", Required: true},
+	{Name: "team", Type: dataInt, Required: true},
+	{Name: "is_leader", Type: dataBool, Required: true},
+	{Name: "draw_index", Type: dataInt, Required: true},
+	{Name: "medal_id", Required: true},
+	{Name: "head_id", Required: true},
+	{Name: "r_arm_id", Required: true},
+	{Name: "l_arm_id", Required: true},
+	{Name: "legs_id", Required: true},
+}, localizedColumns("name")...)
+
+func LoadMedarotLoadouts(src dataSource, name string) ([]MedarotData, error) {
+	var medarots []MedarotData
+	err := readDataFile(src, name, medarotColumns, func(r *dataRecord) {
+		team := r.int("team")
+		if team != int(Team1) && team != int(Team2) {
+			r.errorf("team", "'%d' は %d か %d にしてください", team, Team1, Team2)
+		}
+		medarots = append(medarots, MedarotData{
+			ID:         r.required("id"),
+			Name:       r.required("name"),
+			Names:      r.localizedNames("name"),
+			Team:       TeamID(team),
+			IsLeader:   r.bool("is_leader"),
+			DrawIndex:  r.int("draw_index"),
+			MedalID:    r.required("medal_id"),
+			HeadID:     r.required("head_id"),
+			RightArmID: r.required("r_arm_id"),
+			LeftArmID:  r.required("l_arm_id"),
+			LegsID:     r.required("legs_id"),
+		})
+	})
+	return medarots, err
+}
+
+// medarotRecords は編成を medarots.csv と同じ列の行にする（writeDataFile で書き出すため）
+func medarotRecords(medarots []MedarotData) []*dataRecord {
+	var records []*dataRecord
+	for _, m := range medarots {
+		name := m.Name
+		if original, ok := m.Names[defaultLanguage]; ok {
+			name = original // Localize で置き換える前の名前
+		}
+		values := map[string]string{
+			"id":         m.ID,
+			"name":       name,
+			"team":       strconv.Itoa(int(m.Team)),
+			"is_leader":  strconv.FormatBool(m.IsLeader),
+			"draw_index": strconv.Itoa(m.DrawIndex),
+			"medal_id":   m.MedalID,
+			"head_id":    m.HeadID,
+			"r_arm_id":   m.RightArmID,
+			"l_arm_id":   m.LeftArmID,
+			"legs_id":    m.LegsID,
+		}
+		for _, lang := range nameLanguages {
+			if name, ok := m.Names[lang]; ok {
+				values["name_"+lang] = name
+			}
+		}
+		records = append(records, &dataRecord{values: values})
 	}
+	return records
+}
 
-	gameData.Medarots, err = LoadMedarotLoadouts("data/medarots.csv")
+// LoadAllGameData はバイナリに埋め込んだ標準のデータを読み込む
+func LoadAllGameData() (*GameData, error) {
+	return LoadGameDataDir(embeddedPackSource)
+}
+
+// LoadGameDataDir は source（ディレクトリ・zip・embedded）にあるメダル・パーツ・編成のデータを読み込む。
+// それぞれ medals / parts / medarots に .csv, .json, .yaml, .yml のいずれかの拡張子を付けたファイルを探す。
+// 複数のデータパックを重ねるときは LoadDataPacks を使う
+func LoadGameDataDir(source string) (*GameData, error) {
+	gameData, _, err := LoadDataPacks([]string{source})
 	if err != nil {
-		return nil, fmt.Errorf("medarots.csvの読み込みに失敗: %w", err)
+		return nil, err
 	}
-
 	return gameData, nil
 }
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dataFileExtensions は読み込めるデータファイルの拡張子。同じデータが複数の形式で置かれているとエラーにする
+var dataFileExtensions = []string{".csv", ".json", ".yaml", ".yml"}
+
+// dataKinds はデータの種類ごとの列。JSON / YAML でも同じ名前をキーにする（スキーマは共通）
+var dataKinds = map[string][]dataColumn{
+	"medals":   medalColumns,
+	"parts":    partColumns,
+	"medarots": medarotColumns,
+}
+
+// findDataFile は src から kind に対応するデータファイルを探す。無ければ kind.csv を返す（開くときに見つからないエラーになる）。
+// 複数の形式で置かれていればエラーにするが、検証を続けられるよう最初に見つかったファイルも返す
+func findDataFile(src dataSource, kind string) (string, error) {
+	var found []string
+	for _, ext := range dataFileExtensions {
+		if name := kind + ext; src.exists(name) {
+			found = append(found, name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return kind + ".csv", nil
+	case 1:
+		return found[0], nil
+	}
+	paths := make([]string, len(found))
+	for i, name := range found {
+		paths[i] = src.path(name)
+	}
+	return found[0], fmt.Errorf("%s のデータが複数の形式で置かれています: %s", kind, strings.Join(paths, ", "))
+}
+
+// nestedColumns は入れ子の値を書ける列の名前を返す
+func nestedColumns(columns []dataColumn) map[string]bool {
+	nested := make(map[string]bool)
+	for _, c := range columns {
+		if c.Type == dataNested {
+			nested[c.Name] = true
+		}
+	}
+	return nested
+}
+
+// unknownKeys は JSON / YAML の1要素にある未知のキーを報告する
+func unknownKeys(values map[string]string, columns []dataColumn, where func(string) string, problems *[]error) {
+	known := make(map[string]bool)
+	for _, c := range columns {
+		known[c.Name] = true
+	}
+	for key := range values {
+		if !known[key] {
+			*problems = append(*problems, fmt.Errorf("%s: 未知の列 '%s'", where(key), key))
+		}
+	}
+}
+
+// readJSONRecords は {"schema_version": 2, "records": [...]} の records にある、列名をキーにしたオブジェクトを読む。
+// 版1のファイルはオブジェクトの配列だけを書く。null は NONE として扱う。
+// 入れ子の列 (trait_params など) の値は、CSV のセルと同じく JSON の文字列にして持つ
+func readJSONRecords(src dataSource, name string, columns []dataColumn, problems *[]error) ([]*dataRecord, int, error) {
+	filePath := src.path(name)
+	data, err := fs.ReadFile(src.fsys, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	version := 1
+	if body := bytes.TrimSpace(data); len(body) > 0 && body[0] == '{' {
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", filePath, err)
+		}
+		for key := range doc {
+			if key != schemaVersionKey && key != "records" {
+				return nil, 0, fmt.Errorf("%s: 未知のキー '%s' (%s と records だけを書きます)", filePath, key, schemaVersionKey)
+			}
+		}
+		if err := json.Unmarshal(doc[schemaVersionKey], &version); err != nil || version < 1 {
+			return nil, 0, fmt.Errorf("%s: %s は1以上の整数にしてください", filePath, schemaVersionKey)
+		}
+		data = doc["records"]
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var items []map[string]any
+	if err := dec.Decode(&items); err != nil {
+		return nil, version, fmt.Errorf("%s: オブジェクトの配列として読めません: %w", filePath, err)
+	}
+	nested := nestedColumns(columns)
+	var records []*dataRecord
+	for i, item := range items {
+		where := func(column string) string { return fmt.Sprintf("%s: %d番目の要素", filePath, i+1) }
+		values := make(map[string]string, len(item))
+		for key, v := range item {
+			if nested[key] {
+				if v != nil {
+					text, _ := json.Marshal(v)
+					values[key] = string(text)
+				}
+				continue
+			}
+			switch v := v.(type) {
+			case nil:
+				values[key] = noneValue
+			case string:
+				values[key] = v
+			case json.Number:
+				values[key] = v.String()
+			case bool:
+				values[key] = strconv.FormatBool(v)
+			default:
+				*problems = append(*problems, fmt.Errorf("%s: 列 %s: 入れ子の値は使えません", where(key), key))
+			}
+		}
+		unknownKeys(values, columns, where, problems)
+		records = append(records, &dataRecord{values: values, where: where, errs: problems})
+	}
+	return records, version, nil
+}
+
+// readYAMLRecords は schema_version と records を持つマッピングの、records にある列名をキーにしたマッピングを読む。
+// 版1のファイルはマッピングのシーケンスだけを書く。~ (null) は NONE として扱う。
+// 入れ子の列 (trait_params など) の値は、CSV のセルと同じく JSON の文字列にして持つ
+func readYAMLRecords(src dataSource, name string, columns []dataColumn, problems *[]error) ([]*dataRecord, int, error) {
+	filePath := src.path(name)
+	data, err := fs.ReadFile(src.fsys, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", filePath, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, 1, nil
+	}
+	root, version := doc.Content[0], 1
+	if root.Kind == yaml.MappingNode {
+		var seq *yaml.Node
+		version = 0
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, value := root.Content[i], root.Content[i+1]
+			switch key.Value {
+			case schemaVersionKey:
+				if v, err := strconv.Atoi(value.Value); err == nil && value.Kind == yaml.ScalarNode && v >= 1 {
+					version = v
+				}
+			case "records":
+				seq = value
+			default:
+				return nil, 0, fmt.Errorf("%s:%d: 未知のキー '%s' (%s と records だけを書きます)", filePath, key.Line, key.Value, schemaVersionKey)
+			}
+		}
+		if version == 0 {
+			return nil, 0, fmt.Errorf("%s:%d: %s は1以上の整数にしてください", filePath, root.Line, schemaVersionKey)
+		}
+		if seq == nil || seq.Tag == "!!null" {
+			return nil, version, nil
+		}
+		root = seq
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, version, fmt.Errorf("%s:%d: records はマッピングのシーケンス (- id: ...) にしてください", filePath, root.Line)
+	}
+	nested := nestedColumns(columns)
+	var records []*dataRecord
+	for _, item := range root.Content {
+		if item.Kind != yaml.MappingNode {
+			*problems = append(*problems, fmt.Errorf("%s:%d: 要素がマッピングではありません", filePath, item.Line))
+			continue
+		}
+		values := make(map[string]string)
+		lines := make(map[string]int)
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+			lines[key.Value] = value.Line
+			switch {
+			case nested[key.Value]:
+				if value.Tag == "!!null" {
+					continue
+				}
+				text, err := yamlNodeToJSON(value)
+				if err != nil {
+					*problems = append(*problems, fmt.Errorf("%s:%d: 列 %s: %w", filePath, value.Line, key.Value, err))
+					continue
+				}
+				values[key.Value] = text
+			case value.Kind != yaml.ScalarNode:
+				*problems = append(*problems, fmt.Errorf("%s:%d: 列 %s: 入れ子の値は使えません", filePath, value.Line, key.Value))
+			case value.Tag == "!!null":
+				values[key.Value] = noneValue
+			default:
+				values[key.Value] = value.Value
+			}
+		}
+		itemLine := item.Line
+		where := func(column string) string {
+			if line, ok := lines[column]; ok {
+				return fmt.Sprintf("%s:%d", filePath, line)
+			}
+			return fmt.Sprintf("%s:%d", filePath, itemLine)
+		}
+		unknownKeys(values, columns, where, problems)
+		records = append(records, &dataRecord{values: values, where: where, errs: problems})
+	}
+	return records, version, nil
+}
+
+// yamlNodeToJSON は YAML の入れ子の値を JSON の文字列にする
+func yamlNodeToJSON(node *yaml.Node) (string, error) {
+	var v any
+	if err := node.Decode(&v); err != nil {
+		return "", err
+	}
+	text, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("JSON にできない値です (マッピングのキーは文字列にしてください): %w", err)
+	}
+	return string(text), nil
+}
+
+// jsonValue は列の種類に合わせて値を JSON にする。整数の列の NONE は null にする。
+// 種類に合わない値（整数の列の "abc" など）は文字列のまま書き、読み込むときに問題として報告させる
+func jsonValue(c dataColumn, s string) ([]byte, error) {
+	switch c.Type {
+	case dataInt:
+		if s == noneValue {
+			return []byte("null"), nil
+		}
+		if i, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			return []byte(strconv.Itoa(i)), nil
+		}
+	case dataBool:
+		if b := strings.ToLower(strings.TrimSpace(s)); b == "true" || b == "false" {
+			return []byte(b), nil
+		}
+	case dataNested:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(s), "      ", "  "); err != nil {
+			return nil, fmt.Errorf("列 %s: '%s' は JSON として読めません: %w", c.Name, s, err)
+		}
+		return indented.Bytes(), nil
+	}
+	return json.Marshal(s)
+}
+
+// yamlValue は列の種類に合わせて値を YAML のノードにする。整数の列の NONE は ~ (null) にし、
+// 文字列の列は "007" のような値も文字列のまま書く
+func yamlValue(c dataColumn, s string) (*yaml.Node, error) {
+	switch c.Type {
+	case dataInt:
+		if s == noneValue {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "~"}, nil
+		}
+		if i, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(i)}, nil
+		}
+	case dataBool:
+		if b := strings.ToLower(strings.TrimSpace(s)); b == "true" || b == "false" {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: b}, nil
+		}
+	case dataNested:
+		// JSON は YAML としても読めるので、読んだノードをブロック形式に直して使う
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(s), &doc); err != nil || !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("列 %s: '%s' は JSON として読めません", c.Name, s)
+		}
+		node := doc.Content[0]
+		clearYAMLStyle(node)
+		return node, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}, nil
+}
+
+// clearYAMLStyle はノードとその子の書式 (フロー形式・引用符) を消し、既定のブロック形式で書かせる
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+// writeDataFile は行を拡張子に合った形式で書き出す
+func writeDataFile(filePath string, columns []dataColumn, records []*dataRecord) error {
+	data, err := encodeDataFile(filePath, columns, records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// encodeDataFile は行を filePath の拡張子に合った形式にする。先頭に今のスキーマの版を書き、列は columns の順に並べる
+func encodeDataFile(filePath string, columns []dataColumn, records []*dataRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		fmt.Fprintf(&buf, "# %s: %d\n", schemaVersionKey, dataSchemaVersion)
+		// 任意の列は、どの行にも値が無ければ書かない（name_en などの列を増やしても既存のファイルが変わらないように）
+		var used []dataColumn
+		for _, c := range columns {
+			use := c.Required
+			for _, r := range records {
+				if _, ok := r.values[c.Name]; ok {
+					use = true
+				}
+			}
+			if use {
+				used = append(used, c)
+			}
+		}
+		columns = used
+		w := csv.NewWriter(&buf)
+		var header []string
+		for _, c := range columns {
+			header = append(header, c.Name)
+		}
+		w.Write(header)
+		for _, r := range records {
+			var row []string
+			for _, c := range columns {
+				row = append(row, r.values[c.Name])
+			}
+			w.Write(row)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	case ".json":
+		fmt.Fprintf(&buf, "{\n  \"%s\": %d,\n  \"records\": [\n", schemaVersionKey, dataSchemaVersion)
+		for i, r := range records {
+			var fields []string
+			for _, c := range columns {
+				v, ok := r.values[c.Name]
+				if !ok || (c.Type == dataNested && (strings.TrimSpace(v) == "" || v == noneValue)) {
+					continue // 入れ子の列の空のセルは書かない
+				}
+				key, _ := json.Marshal(c.Name)
+				value, err := jsonValue(c, v)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %d行目: %w", filePath, i+1, err)
+				}
+				fields = append(fields, fmt.Sprintf("      %s: %s", key, value))
+			}
+			buf.WriteString("    {\n" + strings.Join(fields, ",\n") + "\n    }")
+			if i < len(records)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("  ]\n}\n")
+	case ".yaml", ".yml":
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for i, r := range records {
+			item := &yaml.Node{Kind: yaml.MappingNode}
+			for _, c := range columns {
+				v, ok := r.values[c.Name]
+				if !ok || (c.Type == dataNested && (strings.TrimSpace(v) == "" || v == noneValue)) {
+					continue // 入れ子の列の空のセルは書かない
+				}
+				value, err := yamlValue(c, v)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %d行目: %w", filePath, i+1, err)
+				}
+				item.Content = append(item.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: c.Name}, value)
+			}
+			seq.Content = append(seq.Content, item)
+		}
+		doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: schemaVersionKey},
+			{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(dataSchemaVersion)},
+			{Kind: yaml.ScalarNode, Value: "records"},
+			seq,
+		}}
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+		enc.Close()
+	default:
+		return nil, fmt.Errorf("%s: 未対応の形式です (%s のいずれか)", filePath, strings.Join(dataFileExtensions, ", "))
+	}
+	return buf.Bytes(), nil
+}
+
+// dataKindOf はファイル名からデータの種類 (medals / parts / medarots) とその列を決める
+func dataKindOf(filePath string) (string, []dataColumn, error) {
+	kind := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	columns, ok := dataKinds[kind]
+	if !ok {
+		return "", nil, fmt.Errorf("%s: データの種類がわかりません (ファイル名を medals, parts, medarots のいずれかにしてください)", filePath)
+	}
+	return kind, columns, nil
+}
+
+// convertDataFile はデータファイルを別の形式に変換する。古い版のファイルは先に medadata migrate で更新しておく
+func convertDataFile(from, to string) error {
+	_, columns, err := dataKindOf(from)
+	if err != nil {
+		return err
+	}
+	var problems []error
+	src, name := fileSource(from)
+	records, version, err := readRecords(src, name, columns, &problems)
+	if err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(from, version); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%sの読み込みに失敗: %w", from, errors.Join(problems...))
+	}
+	if err := writeDataFile(to, columns, records); err != nil {
+		return fmt.Errorf("%sの書き込みに失敗: %w", to, err)
+	}
+	return nil
+}
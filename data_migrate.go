@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// schemaMigration はデータファイルをスキーマの版 From から From+1 に上げる手順。Apply は1行分の値を書き換える
+type schemaMigration struct {
+	From  int
+	Note  string
+	Apply func(kind string, values map[string]string)
+}
+
+// schemaMigrations は版を1つずつ上げる手順。dataSchemaVersion を上げたら、ここに前の版からの手順を足す
+var schemaMigrations = []schemaMigration{
+	{From: 1, Note: "数値でない値を以前と同じ既定値（威力などは NONE）に置き換え、is_leader を true / false にする", Apply: migrateV1},
+}
+
+// migrateV1 は版1の値を版2の書き方にする。版1は数値でない値を黙って既定値にしていたので、同じ値を明示的に書く
+func migrateV1(kind string, values map[string]string) {
+	for column, v := range values {
+		values[column] = strings.TrimSpace(v)
+	}
+	intOr := func(column, fallback string) {
+		if _, err := strconv.Atoi(values[column]); err != nil {
+			values[column] = fallback
+		}
+	}
+	switch kind {
+	case "medals":
+		intOr("skill_fight", "1")
+	case "parts":
+		intOr("armor", "1")
+		for _, column := range []string{"power", "charge", "cooldown", "defense", "accuracy", "mobility", "propulsion"} {
+			intOr(column, noneValue)
+		}
+	case "medarots":
+		intOr("team", "0")
+		intOr("draw_index", "0")
+		values["is_leader"] = strconv.FormatBool(strings.ToLower(values["is_leader"]) == "true")
+	}
+}
+
+// dataMigration は1つのデータファイルを今の版に上げた結果
+type dataMigration struct {
+	Path   string
+	From   int
+	Notes  []string
+	Before []byte
+	After  []byte
+}
+
+// migrateDataFile は filePath のデータファイルを読み、今のスキーマの版に上げた内容を返す（ファイルは書き換えない）。
+// すでに今の版なら nil を返す
+func migrateDataFile(filePath string) (*dataMigration, error) {
+	kind, columns, err := dataKindOf(filePath)
+	if err != nil {
+		return nil, err
+	}
+	src, name := fileSource(filePath)
+	before, err := fs.ReadFile(src.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var problems []error
+	records, version, err := readRecords(src, name, columns, &problems)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%sの読み込みに失敗: %w", filePath, errors.Join(problems...))
+	}
+	if version > dataSchemaVersion {
+		return nil, checkSchemaVersion(filePath, version)
+	}
+	if version == dataSchemaVersion {
+		return nil, nil
+	}
+	m := &dataMigration{Path: filePath, From: version, Before: before}
+	for _, step := range schemaMigrations {
+		if step.From < version {
+			continue
+		}
+		for _, r := range records {
+			step.Apply(kind, r.values)
+		}
+		m.Notes = append(m.Notes, fmt.Sprintf("v%d → v%d: %s", step.From, step.From+1, step.Note))
+	}
+	if m.After, err = encodeDataFile(filePath, columns, records); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// unversionedDataFiles はスキーマの版を持たず、migrate が扱わないファイル。
+// 行動ルールは読み込み時に見出し行と値を調べ、マニフェストは未知の項目をエラーにするので、書き方が変わったらそこで気づける
+var unversionedDataFiles = []string{"ai_rules.csv", packManifestName}
+
+// runMedadataMigrate は古い版のデータファイル (medals, parts, medarots) を今の版に上げる。-write を付けなければ差分を表示するだけで書き換えない。
+// ai_rules.csv と pack.json は版を持たないので対象外
+func runMedadataMigrate(args []string) error {
+	fs := flag.NewFlagSet("medadata migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "使い方: medadata migrate [-dir data] [-write]\n")
+		fmt.Fprintf(fs.Output(), "medals, parts, medarots を今のスキーマの版 (v%d) に上げる。%s は版を持たないので対象外。\n", dataSchemaVersion, strings.Join(unversionedDataFiles, " と "))
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", basePackDir, "更新するデータのディレクトリ")
+	write := fs.Bool("write", false, "差分を表示するだけでなく、ファイルを書き換える")
+	fs.Parse(args)
+
+	src := dirSource(*dir)
+	var migrations []*dataMigration
+	for _, kind := range []string{"medals", "parts", "medarots"} {
+		name, err := findDataFile(src, kind)
+		if err != nil {
+			return err
+		}
+		if !src.exists(name) {
+			continue
+		}
+		m, err := migrateDataFile(src.path(name))
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			fmt.Printf("%s は今の版 (v%d) です。\n", src.path(name), dataSchemaVersion)
+			continue
+		}
+		migrations = append(migrations, m)
+	}
+	for _, name := range unversionedDataFiles {
+		if src.exists(name) {
+			fmt.Printf("%s は版を持たないので更新しません。\n", src.path(name))
+		}
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+	for _, m := range migrations {
+		fmt.Printf("%s: v%d → v%d\n", m.Path, m.From, dataSchemaVersion)
+		for _, note := range m.Notes {
+			fmt.Printf("  %s\n", note)
+		}
+		fmt.Print(unifiedDiff(m.Path+" (v"+strconv.Itoa(m.From)+")", m.Path+" (v"+strconv.Itoa(dataSchemaVersion)+")", m.Before, m.After))
+	}
+	if !*write {
+		fmt.Printf("%d件のファイルを更新できます。書き換えるには -write を付けてください。\n", len(migrations))
+		return nil
+	}
+	for _, m := range migrations {
+		if err := os.WriteFile(m.Path, m.After, 0644); err != nil {
+			return fmt.Errorf("%sの書き込みに失敗: %w", m.Path, err)
+		}
+	}
+	fmt.Printf("%d件のファイルを v%d に更新しました。\n", len(migrations), dataSchemaVersion)
+	return nil
+}
+
+// diffContext は差分の前後に表示する変わらない行の数
+const diffContext = 3
+
+// diffLine は差分の1行。op は ' '（共通）・'-'（削除）・'+'（追加）
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff は before と after の行単位の差分を unified 形式で返す。
+// 最長共通部分列を表で求めるので、行数の積に比例したメモリを使う（データファイルの大きさなら問題ない）
+func unifiedDiff(beforeName, afterName string, before, after []byte) string {
+	a, b := splitLines(before), splitLines(after)
+	// lcs[i][j] は a[i:] と b[j:] の最長共通部分列の長さ
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var lines []diffLine
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", beforeName, afterName)
+	aLine, bLine := 1, 1 // lines[k] の位置での a と b の行番号
+	for k := 0; k < len(lines); {
+		if lines[k].op == ' ' {
+			aLine++
+			bLine++
+			k++
+			continue
+		}
+		// 変更のまとまり（間の共通行が diffContext*2 以下なら1つにまとめる）を前後の共通行ごと出す
+		start := max(0, k-diffContext)
+		end := k
+		for gap := 0; end < len(lines) && gap <= diffContext*2; end++ {
+			if lines[end].op == ' ' {
+				gap++
+			} else {
+				gap = 0
+			}
+		}
+		for end > k && lines[end-1].op == ' ' {
+			end--
+		}
+		end = min(len(lines), end+diffContext)
+		aStart, bStart := aLine-(k-start), bLine-(k-start)
+		var aCount, bCount int
+		var body strings.Builder
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				aCount++
+			}
+			if l.op != '-' {
+				bCount++
+			}
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			body.WriteByte('\n')
+		}
+		for _, l := range lines[k:end] {
+			if l.op != '+' {
+				aLine++
+			}
+			if l.op != '-' {
+				bLine++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n%s", hunkRange(aStart, aCount), hunkRange(bStart, bCount), body.String())
+		k = end
+	}
+	return out.String()
+}
+
+// hunkRange は差分のまとまりの "開始行,行数" を書く。行が無ければ直前の行番号を書く（unified 形式の決まり）
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines は行に分ける。改行コードの違い (CRLF) は差分に出さない
+func splitLines(data []byte) []string {
+	text := string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMigrateDataFileFromV1 は testdata/migrate/v1 の版1のファイルを上げると、v2 の同じ名前のファイルと同じ内容になることを確かめる
+func TestMigrateDataFileFromV1(t *testing.T) {
+	for _, name := range []string{"parts.csv", "medarots.csv"} {
+		t.Run(name, func(t *testing.T) {
+			m, err := migrateDataFile(filepath.Join("testdata", "migrate", "v1", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m == nil || m.From != 1 {
+				t.Fatalf("版1のファイルとして扱われません: %+v", m)
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", "migrate", "v2", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(m.After) != string(want) {
+				t.Errorf("版2に上げた内容が違います\n%s", unifiedDiff("want", "got", want, m.After))
+			}
+			if again, err := migrateDataFile(filepath.Join("testdata", "migrate", "v2", name)); err != nil || again != nil {
+				t.Errorf("今の版のファイルを上げ直そうとしました: %+v, %v", again, err)
+			}
+		})
+	}
+}
+
+// numberedLines は i 行目が "x" を i 個並べた行になっている n 行を返す。changed にある行はその内容で置き換える
+func numberedLines(n int, changed map[int]string) []byte {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if text, ok := changed[i]; ok {
+			b.WriteString(text + "\n")
+		} else {
+			b.WriteString(strings.Repeat("x", i) + "\n")
+		}
+	}
+	return []byte(b.String())
+}
+
+// TestUnifiedDiff は変更のまとまりの分け方と、空のファイルとの差分を確かめる
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after []byte
+		want          string
+	}{
+		{
+			name:   "変更なし",
+			before: []byte("a\nb\n"),
+			after:  []byte("a\r\nb\r\n"),
+			want:   "--- a\n+++ b\n",
+		},
+		{
+			name:   "空のファイルへの追加",
+			before: nil,
+			after:  []byte("a\nb\n"),
+			want:   "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:   "すべて削除",
+			before: []byte("a\nb\n"),
+			after:  nil,
+			want:   "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			// 間の共通行が diffContext*2 (6行) 以下なら1つのまとまりにする
+			name:   "近い変更はまとめる",
+			before: numberedLines(12, nil),
+			after:  numberedLines(12, map[int]string{2: "B", 9: "I"}),
+			want: "--- a\n+++ b\n@@ -1,12 +1,12 @@\n x\n-xx\n+B\n xxx\n xxxx\n xxxxx\n xxxxxx\n xxxxxxx\n xxxxxxxx\n" +
+				"-xxxxxxxxx\n+I\n xxxxxxxxxx\n xxxxxxxxxxx\n xxxxxxxxxxxx\n",
+		},
+		{
+			name:   "離れた変更は分ける",
+			before: numberedLines(12, nil),
+			after:  numberedLines(12, map[int]string{2: "B", 10: "J"}),
+			want: "--- a\n+++ b\n@@ -1,5 +1,5 @@\n x\n-xx\n+B\n xxx\n xxxx\n xxxxx\n" +
+				"@@ -7,6 +7,6 @@\n xxxxxxx\n xxxxxxxx\n xxxxxxxxx\n-xxxxxxxxxx\n+J\n xxxxxxxxxxx\n xxxxxxxxxxxx\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("a", "b", tt.before, tt.after); got != tt.want {
+				t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// packManifestName は各データパックのディレクトリに置くマニフェストのファイル名
+const packManifestName = "pack.json"
+
+// basePackDir はリポジトリの中の標準データのディレクトリ。編集したデータを検証・監視するときに使う（起動時は埋め込みのデータを読む）
+const basePackDir = "data"
+
+// PackManifest はデータパックの名前・版・依存先。
+// Dependencies には "base" や "base>=1.0" のように、先に読み込まれている必要のあるパックを書く
+type PackManifest struct {
+	Name         string
+	Version      string
+	Description  string
+	Dependencies []string
+}
+
+// DataPack は読み込んだデータパック1つ。Source はディレクトリ・zip ファイル・embedded のいずれか
+type DataPack struct {
+	Source   string
+	Manifest PackManifest
+}
+
+// PackOverride は複数のパックが同じIDを定義した行。最後のパックの行が使われる。
+// 後のパックが前のパックに依存していない（意図した上書きかわからない）ときは Conflict にする
+type PackOverride struct {
+	Kind     string
+	ID       string
+	Packs    []string
+	Conflict bool
+}
+
+// PackReport はデータパックを重ねた結果の報告
+type PackReport struct {
+	Packs     []DataPack
+	Added     map[string]int // パックごとに新しく追加した行の数
+	Overrides []PackOverride
+}
+
+// LoadPackManifest は src のマニフェストを読む。無ければパックの場所の名前（拡張子を除く）をパック名にする
+func LoadPackManifest(src dataSource) (PackManifest, error) {
+	base := filepath.Base(src.location)
+	manifest := PackManifest{Name: strings.TrimSuffix(base, filepath.Ext(base))}
+	path := src.path(packManifestName)
+	data, err := fs.ReadFile(src.fsys, packManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("%sの読み込みに失敗: %w", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("%s の解析に失敗: %w", path, err)
+	}
+	if manifest.Name == "" {
+		return manifest, fmt.Errorf("%s: Name が空です", path)
+	}
+	return manifest, nil
+}
+
+// parseDependency は "name" / "name>=version" を名前と最低限の版に分ける
+func parseDependency(dep string) (name, minVersion string) {
+	name, minVersion, _ = strings.Cut(dep, ">=")
+	return strings.TrimSpace(name), strings.TrimSpace(minVersion)
+}
+
+// compareVersions は "1.2.10" のような版を比べる。数字の部分は数として、それ以外は文字列として比べる。
+// 足りない部分は 0 とみなす（"1.0" と "1.0.0" は同じ版）
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xi, xerr := strconv.Atoi(x)
+		yi, yerr := strconv.Atoi(y)
+		switch {
+		case xerr == nil && yerr == nil && xi != yi:
+			if xi < yi {
+				return -1
+			}
+			return 1
+		case (xerr != nil || yerr != nil) && x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
+
+// LoadDataPacks は sources のデータパックを順に重ねて読み込む。
+// 後のパックは新しいメダル・パーツ・編成を追加するか、同じIDの行を上書きする。パックに無いデータのファイルは前のパックのまま使う。
+// 読み込みの問題はすべてまとめて返し、そのときも読めた分を重ねた結果を返す（medadata validate が続きを検査できるように）
+func LoadDataPacks(sources []string) (*GameData, *PackReport, error) {
+	var problems []error
+	collect := func(path string, err error) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			problems = append(problems, joined.Unwrap()...)
+		} else if err != nil {
+			problems = append(problems, fmt.Errorf("%sの読み込みに失敗: %w", filepath.Base(path), err))
+		}
+	}
+
+	report := &PackReport{Added: make(map[string]int)}
+	index := make(map[string]int)
+	requires := make(map[string]map[string]bool) // パックが直接・間接に依存するパック
+	var srcs []dataSource
+	for _, source := range sources {
+		src, closePack, err := openPackSource(source)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		defer closePack()
+		manifest, err := LoadPackManifest(src)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		if _, dup := index[manifest.Name]; dup {
+			problems = append(problems, fmt.Errorf("%s: パック %s が2回指定されています", source, manifest.Name))
+			continue
+		}
+		requires[manifest.Name] = make(map[string]bool)
+		for _, dep := range manifest.Dependencies {
+			name, minVersion := parseDependency(dep)
+			i, ok := index[name]
+			if !ok {
+				problems = append(problems, fmt.Errorf("%s: 依存先のパック %s がこれより前に読み込まれていません", manifest.Name, name))
+				continue
+			}
+			if have := report.Packs[i].Manifest.Version; minVersion != "" && compareVersions(have, minVersion) < 0 {
+				problems = append(problems, fmt.Errorf("%s: %s %s 以上が必要です (読み込んだのは %s)", manifest.Name, name, minVersion, have))
+			}
+			requires[manifest.Name][name] = true
+			for indirect := range requires[name] {
+				requires[manifest.Name][indirect] = true
+			}
+		}
+		index[manifest.Name] = len(report.Packs)
+		report.Packs = append(report.Packs, DataPack{Source: source, Manifest: manifest})
+		srcs = append(srcs, src)
+	}
+
+	gameData := &GameData{AllParts: make(map[string]*Part)}
+	providers := make(map[string][]string) // "kind/id" ごとに、その行を定義したパック
+	define := func(pack, kind, id string) bool {
+		key := kind + "/" + id
+		providers[key] = append(providers[key], pack)
+		if len(providers[key]) == 1 {
+			report.Added[pack]++
+			return true
+		}
+		return false
+	}
+	for _, kind := range []string{"medals", "parts", "medarots"} {
+		found := false
+		for i, pack := range report.Packs {
+			src := srcs[i]
+			path, err := findDataFile(src, kind)
+			if !src.exists(path) {
+				continue
+			}
+			found = true
+			if err != nil {
+				problems = append(problems, err)
+			}
+			name := pack.Manifest.Name
+			switch kind {
+			case "medals":
+				medals, err := LoadMedals(src, path)
+				collect(src.path(path), err)
+				for _, medal := range medals {
+					if define(name, kind, medal.ID) {
+						gameData.Medals = append(gameData.Medals, medal)
+						continue
+					}
+					for i := range gameData.Medals {
+						if gameData.Medals[i].ID == medal.ID {
+							gameData.Medals[i] = medal
+						}
+					}
+				}
+			case "parts":
+				parts, err := LoadParts(src, path)
+				collect(src.path(path), err)
+				ids := make([]string, 0, len(parts))
+				for id := range parts {
+					ids = append(ids, id)
+				}
+				sort.Strings(ids)
+				for _, id := range ids {
+					define(name, kind, id)
+					gameData.AllParts[id] = parts[id]
+				}
+			case "medarots":
+				medarots, err := LoadMedarotLoadouts(src, path)
+				collect(src.path(path), err)
+				for _, m := range medarots {
+					if define(name, kind, m.ID) {
+						gameData.Medarots = append(gameData.Medarots, m)
+						continue
+					}
+					for i := range gameData.Medarots {
+						if gameData.Medarots[i].ID == m.ID {
+							gameData.Medarots[i] = m
+						}
+					}
+				}
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Errorf("%s のデータがどのパックにもありません (%s)", kind, strings.Join(sources, ", ")))
+		}
+	}
+
+	keys := make([]string, 0, len(providers))
+	for key, packs := range providers {
+		if len(packs) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		kind, id, _ := strings.Cut(key, "/")
+		packs := providers[key]
+		override := PackOverride{Kind: kind, ID: id, Packs: packs}
+		last := packs[len(packs)-1]
+		for _, earlier := range packs[:len(packs)-1] {
+			if earlier != last && !requires[last][earlier] {
+				override.Conflict = true
+			}
+		}
+		report.Overrides = append(report.Overrides, override)
+	}
+	return gameData, report, errors.Join(problems...)
+}
+
+// Conflicts は依存関係の無いパック同士で上書きしている行を返す
+func (r *PackReport) Conflicts() []PackOverride {
+	var conflicts []PackOverride
+	for _, o := range r.Overrides {
+		if o.Conflict {
+			conflicts = append(conflicts, o)
+		}
+	}
+	return conflicts
+}
+
+// Write は読み込んだパックと、上書き・競合の一覧を書き出す
+func (r *PackReport) Write(w io.Writer) {
+	fmt.Fprintln(w, "データパック（後ろほど優先）:")
+	for _, pack := range r.Packs {
+		m := pack.Manifest
+		line := fmt.Sprintf("  %s", m.Name)
+		if m.Version != "" {
+			line += " " + m.Version
+		}
+		line += fmt.Sprintf(" (%s) 追加 %d件", pack.Source, r.Added[m.Name])
+		if len(m.Dependencies) > 0 {
+			line += " 依存: " + strings.Join(m.Dependencies, ", ")
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(r.Overrides) == 0 {
+		fmt.Fprintln(w, "上書きされた行はありません。")
+		return
+	}
+	fmt.Fprintln(w, "上書きされた行:")
+	for _, o := range r.Overrides {
+		mark := ""
+		if o.Conflict {
+			mark = "  [競合] 依存関係の無いパック同士です"
+		}
+		fmt.Fprintf(w, "  %s/%s: %s (%s を使用)%s\n", o.Kind, o.ID, strings.Join(o.Packs, " → "), o.Packs[len(o.Packs)-1], mark)
+	}
+	if conflicts := r.Conflicts(); len(conflicts) > 0 {
+		fmt.Fprintf(w, "競合が%d件あります。意図した上書きなら、後のパックのマニフェストの Dependencies に前のパックを書いてください。\n", len(conflicts))
+	}
+}
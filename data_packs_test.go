@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCompareVersions は版の数字の部分を数として、それ以外を文字列として比べることを確かめる
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2.10", "1.2.9", 1},
+		{"1.2", "1.10", -1},
+		{"1.0", "1.0.0", 0},
+		{"1.0", "1.0.1", -1},
+		{"2.0", "10.0", -1},
+		{"1.0.beta", "1.0.alpha", 1},
+		{"1.rc1", "1.2", 1},
+		{"1.2.x", "1.2.x", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// writeTestPack は標準データのメダル M-01 を上書きするデータパックを一時ディレクトリに作る
+func writeTestPack(t *testing.T, manifest string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		packManifestName: manifest,
+		"medals.csv": "# schema_version: 2\n" +
+			"id,name_jp,personality_jp,medaforce_jp,attribute_jp,skill_shoot,skill_fight,skill_scan,skill_support,name_en\n" +
+			"M-01,カブト改,ランダムターゲット,バーサーク,炎,20,5,3,2,Kabuto Kai\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestLoadDataPacksReportsConflicts は標準データに依存するパックの上書きは競合にせず、
+// 依存していないパックの上書きだけを競合として報告することを確かめる
+func TestLoadDataPacksReportsConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		conflict bool
+	}{
+		{"依存あり", `{"Name": "kai", "Version": "1.0", "Dependencies": ["base>=1.0"]}`, false},
+		{"依存なし", `{"Name": "kai", "Version": "1.0"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, report, err := LoadDataPacks([]string{basePackDir, writeTestPack(t, tt.manifest)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if medal := findMedalByID(data.Medals, "M-01"); medal == nil || medal.Name != "カブト改" {
+				t.Errorf("後のパックのメダルで上書きされていません: %+v", medal)
+			}
+			if len(report.Overrides) != 1 || report.Overrides[0].ID != "M-01" {
+				t.Fatalf("上書きされた行 = %+v", report.Overrides)
+			}
+			if got := len(report.Conflicts()) > 0; got != tt.conflict {
+				t.Errorf("競合 = %v, want %v (%+v)", got, tt.conflict, report.Overrides)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"archive/zip"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// embeddedData は標準のデータパック（data ディレクトリ）。フォントと同じくバイナリに埋め込み、どこから起動しても読めるようにする
+//
+//go:embed data
+var embeddedData embed.FS
+
+// embeddedPackSource は埋め込んだ標準データを表すパックの指定
+const embeddedPackSource = "embedded"
+
+// dataSource はデータファイルを読む場所。location はエラーに出すパックの場所（ディレクトリ・zip・embedded）
+type dataSource struct {
+	fsys     fs.FS
+	location string
+}
+
+// path はエラーに出すためのファイルの場所を返す
+func (s dataSource) path(name string) string {
+	return filepath.Join(s.location, name)
+}
+
+// exists は name のファイルがあるかを返す
+func (s dataSource) exists(name string) bool {
+	_, err := fs.Stat(s.fsys, name)
+	return err == nil
+}
+
+// dirSource は OS のディレクトリをデータの読み込み元にする
+func dirSource(dir string) dataSource {
+	return dataSource{fsys: os.DirFS(dir), location: dir}
+}
+
+// fileSource は OS のファイルのパスを、読み込み元とその中のファイル名に分ける
+func fileSource(filePath string) (dataSource, string) {
+	return dirSource(filepath.Dir(filePath)), filepath.Base(filePath)
+}
+
+// openPackSource はデータパックを開く。source は embedded（埋め込みの標準データ）、.zip ファイル、ディレクトリのいずれか。
+// zip の中身が1つのフォルダにまとめられていれば、そのフォルダをパックとして扱う。返した関数で閉じる
+func openPackSource(source string) (dataSource, func() error, error) {
+	noClose := func() error { return nil }
+	if source == embeddedPackSource {
+		sub, err := fs.Sub(embeddedData, "data")
+		return dataSource{fsys: sub, location: embeddedPackSource}, noClose, err
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return dataSource{}, noClose, fmt.Errorf("データパック %s が見つかりません: %w", source, err)
+	}
+	if info.IsDir() {
+		return dirSource(source), noClose, nil
+	}
+	if !strings.EqualFold(filepath.Ext(source), ".zip") {
+		return dataSource{}, noClose, fmt.Errorf("データパック %s はディレクトリか .zip ファイルにしてください", source)
+	}
+	z, err := zip.OpenReader(source)
+	if err != nil {
+		return dataSource{}, noClose, fmt.Errorf("%sの読み込みに失敗: %w", source, err)
+	}
+	src := dataSource{fsys: z, location: source}
+	if entries, err := fs.ReadDir(z, "."); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		sub, err := fs.Sub(z, entries[0].Name())
+		if err != nil {
+			z.Close()
+			return dataSource{}, noClose, err
+		}
+		src = dataSource{fsys: sub, location: path.Join(source, entries[0].Name())}
+	}
+	return src, z.Close, nil
+}
+
+// PackSources は標準のデータパック（base が空なら埋め込みのデータ）に、カンマ区切りで指定されたパックを後ろに並べる（後ろほど優先）
+func PackSources(base, extra string) []string {
+	if base == "" {
+		base = embeddedPackSource
+	}
+	sources := []string{base}
+	for _, source := range strings.Split(extra, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// statRange はパーツやメダルの数値として妥当な範囲
+type statRange struct {
+	Name     string
+	Min, Max int
+	get      func(p *Part) int
+	attack   bool // 攻撃パーツ（行動の種類が NONE でない）だけに適用する
+}
+
+// partStatRanges はパーツの数値の妥当な範囲。攻撃しないパーツの威力などは NONE（既定値）なので範囲を調べない
+var partStatRanges = []statRange{
+	{Name: "armor", Min: 1, Max: 999, get: func(p *Part) int { return p.Armor }},
+	{Name: "power", Min: 1, Max: 999, attack: true, get: func(p *Part) int { return p.Power }},
+	{Name: "charge", Min: 1, Max: 999, attack: true, get: func(p *Part) int { return p.Charge }},
+	{Name: "cooldown", Min: 1, Max: 999, attack: true, get: func(p *Part) int { return p.Cooldown }},
+	{Name: "accuracy", Min: 0, Max: 100, attack: true, get: func(p *Part) int { return p.Accuracy }},
+	{Name: "defense", Min: 0, Max: 100, get: func(p *Part) int { return p.Defense }},
+	{Name: "mobility", Min: 0, Max: 100, get: func(p *Part) int { return p.Mobility }},
+	{Name: "propulsion", Min: 0, Max: 100, get: func(p *Part) int { return p.Propulsion }},
+}
+
+// medalSkillRange はメダルのスキルレベルの妥当な範囲
+var medalSkillRange = statRange{Name: "skill_fight", Min: 0, Max: 99}
+
+// ValidateGameData は読み込んだデータの整合性を調べ、見つかった問題を1つずつ返す。
+// 起動時の InitializeAllMedarots は見つからないメダルやパーツを代わりのもので埋めてしまうので、データを編集したらこれで確かめる。
+func ValidateGameData(gameData *GameData) []error {
+	var problems []error
+	report := func(file, format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%s: %s", file, fmt.Sprintf(format, args...)))
+	}
+
+	medals := make(map[string]bool)
+	for _, medal := range gameData.Medals {
+		medals[medal.ID] = true
+		if medal.SkillLevel < medalSkillRange.Min || medal.SkillLevel > medalSkillRange.Max {
+			report("medals", "%s (%s): %s %d が範囲 %d〜%d の外です", medal.ID, medal.Name, medalSkillRange.Name, medal.SkillLevel, medalSkillRange.Min, medalSkillRange.Max)
+		}
+	}
+
+	partIDs := make([]string, 0, len(gameData.AllParts))
+	for id := range gameData.AllParts {
+		partIDs = append(partIDs, id)
+	}
+	sort.Strings(partIDs)
+	for _, id := range partIDs {
+		part := gameData.AllParts[id]
+		attack := part.Category != CategoryNone
+		if part.Type == PartTypeLegs && attack {
+			report("parts", "%s (%s): 脚パーツの action_category は NONE にしてください", id, part.PartName)
+		}
+		if attack && part.Trait == TraitNone {
+			report("parts", "%s (%s): 攻撃パーツに action_trait がありません", id, part.PartName)
+		}
+		for _, r := range partStatRanges {
+			if r.attack && !attack {
+				continue
+			}
+			if v := r.get(part); v < r.Min || v > r.Max {
+				report("parts", "%s (%s): %s %d が範囲 %d〜%d の外です", id, part.PartName, r.Name, v, r.Min, r.Max)
+			}
+		}
+	}
+
+	slots := []struct {
+		column string
+		want   PartType
+		get    func(m *MedarotData) string
+	}{
+		{"head_id", PartTypeHead, func(m *MedarotData) string { return m.HeadID }},
+		{"r_arm_id", PartTypeRArm, func(m *MedarotData) string { return m.RightArmID }},
+		{"l_arm_id", PartTypeLArm, func(m *MedarotData) string { return m.LeftArmID }},
+		{"legs_id", PartTypeLegs, func(m *MedarotData) string { return m.LegsID }},
+	}
+	members := make(map[TeamID][]*MedarotData)
+	for i := range gameData.Medarots {
+		m := &gameData.Medarots[i]
+		members[m.Team] = append(members[m.Team], m)
+		if !medals[m.MedalID] {
+			report("medarots", "%s (%s): medal_id '%s' は medals にありません", m.ID, m.Name, m.MedalID)
+		}
+		for _, slot := range slots {
+			id := slot.get(m)
+			part, ok := gameData.AllParts[id]
+			if !ok {
+				report("medarots", "%s (%s): %s '%s' は parts にありません", m.ID, m.Name, slot.column, id)
+				continue
+			}
+			if part.Type != slot.want {
+				report("medarots", "%s (%s): %s '%s' は %s パーツです (%s が必要)", m.ID, m.Name, slot.column, id, part.Type, slot.want)
+			}
+		}
+	}
+
+	for _, team := range []TeamID{Team1, Team2} {
+		list := members[team]
+		if len(list) == 0 {
+			report("medarots", "%s にメダロットがいません", teamLabel(team))
+			continue
+		}
+		if len(list) > PlayersPerTeam {
+			report("medarots", "%s のメダロットが %d 体います (最大 %d 体)", teamLabel(team), len(list), PlayersPerTeam)
+		}
+		leaders := 0
+		drawIndexes := make(map[int]string)
+		for _, m := range list {
+			if m.IsLeader {
+				leaders++
+			}
+			if m.DrawIndex < 0 || m.DrawIndex >= PlayersPerTeam {
+				report("medarots", "%s (%s): draw_index %d が範囲 0〜%d の外です", m.ID, m.Name, m.DrawIndex, PlayersPerTeam-1)
+			}
+			if other, dup := drawIndexes[m.DrawIndex]; dup {
+				report("medarots", "%s (%s): draw_index %d が %s と重なっています", m.ID, m.Name, m.DrawIndex, other)
+			} else {
+				drawIndexes[m.DrawIndex] = m.ID
+			}
+		}
+		if leaders != 1 {
+			report("medarots", "%s のリーダーが %d 体います (ちょうど1体にしてください)", teamLabel(team), leaders)
+		}
+	}
+	return problems
+}
+
+// validateDataDir は sources のデータパックを重ねて読み込み、読み込みの問題と重ねた結果の整合性の問題をまとめて返す。
+// 読み込みに失敗したファイルがあっても、読めた行で残りの検査を続ける。
+func validateDataDir(sources []string) []error {
+	var problems []error
+	gameData, _, err := LoadDataPacks(sources)
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		problems = append(problems, joined.Unwrap()...)
+	}
+	problems = append(problems, ValidateGameData(gameData)...)
+
+	// 行動ルールは標準データのものだけを調べる
+	if src, closeData, err := openPackSource(sources[0]); err == nil {
+		defer closeData()
+		if src.exists("ai_rules.csv") {
+			problems = append(problems, ValidateAIRules(src, "ai_rules.csv", gameData)...)
+		}
+	}
+	return problems
+}
+
+// runMedadata は "medadata" サブコマンド。
+//
+//	medarot-ebiten medadata validate [-dir data] [-packs mods/a,mods/b.zip]
+//	medarot-ebiten medadata packs [-data data] -packs mods/a,mods/b.zip
+//	medarot-ebiten medadata convert data/parts.csv data/parts.yaml
+func runMedadata(args []string) error {
+	usage := fmt.Errorf("使い方: medadata validate [-dir data] [-packs パック,...] / medadata packs -packs パック,... / medadata migrate [-dir data] [-write] (medals, parts, medarots のみ) / medadata convert <変換元> <変換先>")
+	if len(args) == 0 {
+		return usage
+	}
+	switch args[0] {
+	case "validate":
+		return runMedadataValidate(args[1:])
+	case "packs":
+		return runMedadataPacks(args[1:])
+	case "migrate":
+		return runMedadataMigrate(args[1:])
+	case "convert":
+		if len(args) != 3 {
+			return usage
+		}
+		if err := convertDataFile(args[1], args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("%s を %s に変換しました。\n", args[1], args[2])
+		return nil
+	}
+	return usage
+}
+
+// runMedadataValidate はデータを検証し、問題があれば一覧を表示して終了コード1で終わる
+func runMedadataValidate(args []string) error {
+	fs := flag.NewFlagSet("medadata validate", flag.ExitOnError)
+	dir := fs.String("dir", basePackDir, "検証するデータのディレクトリか zip (embedded なら埋め込みのデータ)")
+	packs := fs.String("packs", "", "-dir の上に重ねて検証するデータパックのディレクトリか zip (カンマ区切り、後ろほど優先)")
+	fs.Parse(args)
+
+	dirs := PackSources(*dir, *packs)
+	problems := validateDataDir(dirs)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	target := strings.Join(dirs, " + ")
+	if len(problems) > 0 {
+		fmt.Printf("%s に%d件の問題があります。\n", target, len(problems))
+		os.Exit(1)
+	}
+	fmt.Printf("%s に問題はありません。\n", target)
+	return nil
+}
+
+// runMedadataPacks はデータパックを重ねた結果（追加・上書きした行と、依存関係の無いパック同士の競合）を表示する。
+// 読み込みの問題か競合があれば終了コード1で終わる
+func runMedadataPacks(args []string) error {
+	fs := flag.NewFlagSet("medadata packs", flag.ExitOnError)
+	dataDir := fs.String("data", "", "標準データの代わりに読むデータのディレクトリか zip (省略時は埋め込みのデータ)")
+	packs := fs.String("packs", "", "標準データの上に重ねるデータパックのディレクトリか zip (カンマ区切り、後ろほど優先)")
+	fs.Parse(args)
+
+	_, report, err := LoadDataPacks(PackSources(*dataDir, *packs))
+	report.Write(os.Stdout)
+	if err != nil {
+		fmt.Printf("読み込みに問題があります:\n%v\n", err)
+		os.Exit(1)
+	}
+	if len(report.Conflicts()) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"sort"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -17,8 +19,15 @@ type Game struct {
 	Config                Config
 	MplusFont             text.Face
 	TickCount             int
+	Seed                  uint64
+	rngSource             *rand.PCG
+	rng                   *rand.Rand
+	aiRNGSource           *rand.PCG
+	aiRNG                 *rand.Rand
+	teamAI                map[TeamID]AIStrategy
 	DebugMode             bool
 	State                 GameState
+	BattleMode            BattleMode
 	PlayerTeam            TeamID
 	actionQueue           []*Medarot
 	sortedMedarotsForDraw []*Medarot
@@ -31,60 +40,107 @@ type Game struct {
 	winner                TeamID
 	restartRequested      bool
 	playerMedarotToAct    *Medarot
+	SaveFilePath          string
+	ReplayDir             string
+	recordReplay          bool
+	recorder              *Replay
+	replay                *replayPlayer
+	autoAcknowledge       bool
+	battleLog             []*BattleLogEntry
+	autoBattle            map[string]bool
+	autoBattleAI          string
+	LogExportDir          string
+	LoadoutPath           string
+	hotReload             *hotReloader
+	simAccumulator        time.Duration
+	lastUpdateTime        time.Time
+	logger                *log.Logger // バトルの進行ログの出力先。先読みや UI なしのシミュレーションでは quietLogger
 }
 
+// simulationTickDuration は1シミュレーションティックあたりの実時間
+const simulationTickDuration = time.Second / SimulationTicksPerSecond
+
+// maxSimulationCatchUp は1フレームで消化する経過時間の上限。ウィンドウのドラッグ等で
+// 更新が長時間止まった後に、大量のティックを一度に進めてしまうのを防ぐ。
+const maxSimulationCatchUp = 250 * time.Millisecond
+
 func NewGame(gameData *GameData, config Config, font text.Face) *Game {
+	g := newBattle(gameData, config, log.Default())
+	g.MplusFont = font
+	g.ui = NewUI(g)
+	log.Println("Game initialized successfully.")
+	return g
+}
+
+// newBattle は UI を持たないバトルを初期化する。NewGame はこれに UI を載せる。
+// バトルの進行ログは logger に書く。
+func newBattle(gameData *GameData, config Config, logger *log.Logger) *Game {
 	g := &Game{
 		GameData:              gameData,
 		Config:                config,
-		MplusFont:             font,
 		TickCount:             0,
 		DebugMode:             true,
 		State:                 StatePlaying,
+		BattleMode:            ModeWait,
 		PlayerTeam:            Team1,
 		actionQueue:           make([]*Medarot, 0),
 		sortedMedarotsForDraw: make([]*Medarot, 0),
 		playerMedarotToAct:    nil,
+		teamAI:                make(map[TeamID]AIStrategy),
+		autoBattle:            make(map[string]bool),
+		autoBattleAI:          string(AINormal),
+		SaveFilePath:          "savegame.json",
+		ReplayDir:             "replays",
+		LogExportDir:          "logs",
+		LoadoutPath:           defaultLoadoutPath,
+		logger:                logger,
 	}
-	g.Medarots = InitializeAllMedarots(g.GameData)
+	g.SetSeed(uint64(time.Now().UnixNano()))
+	g.Medarots = InitializeAllMedarots(g.GameData, g.logger)
 	if len(g.Medarots) == 0 {
 		log.Fatal("No medarots were initialized.")
 	}
 	g.initializeMedarotLists()
-	g.ui = NewUI(g)
-	log.Println("Game initialized successfully.")
 	return g
 }
 func (g *Game) Update() error {
 	g.ui.ebitenui.Update()
+	g.ui.updateToast()
+	if g.replay != nil {
+		updateReplayControls(g, g.ui.menuBar.replay)
+	} else {
+		updateAutoBattleControls(g, g.ui.menuBar.autoBattle)
+	}
+	if g.ui.loadoutEditor != nil {
+		// 編成画面を開いている間はバトルを止める（データの再読み込みも閉じるまで待つ）
+		g.stopSimulationClock()
+		return nil
+	}
+	g.updateHotReload()
 	if g.restartRequested {
 		g.restartRequested = false
 	}
 	switch g.State {
 	case StatePlaying:
-		g.TickCount++
-		g.updateProgress()
-		g.processReadyQueue()
-		g.processIdleMedarots()
-		g.checkGameEnd()
+		if g.replay != nil && g.replay.paused {
+			g.stopSimulationClock()
+		} else {
+			g.advanceSimulation()
+		}
 		updateAllInfoPanels(g)
 		if g.ui.battlefieldWidget != nil {
 			g.ui.battlefieldWidget.UpdatePositions()
 		}
+		// アクティブモードでは StatePlaying のまま行動選択モーダルを開く
+		g.showPendingActionModal()
 	case StatePlayerActionSelect:
-		if g.ui.actionModal == nil && g.playerMedarotToAct != nil {
-			g.ui.ShowActionModal(g, g.playerMedarotToAct)
-		}
+		g.stopSimulationClock()
+		g.showPendingActionModal()
 	case StateMessage, StateGameOver:
+		g.stopSimulationClock()
 		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 			if g.State == StateMessage {
-				g.ui.HideMessageWindow()
-				if g.postMessageCallback != nil {
-					g.postMessageCallback()
-					g.postMessageCallback = nil
-				}
-				g.State = StatePlaying
-				g.processIdleMedarots()
+				g.acknowledgeMessage()
 			} else if g.State == StateGameOver {
 			}
 		}
@@ -92,6 +148,102 @@ func (g *Game) Update() error {
 	return nil
 }
 
+// SetSeed はバトル用の乱数生成器を指定したシードで初期化する。
+// AIの思考用の乱数は命中判定などとは別系列にする。
+func (g *Game) SetSeed(seed uint64) {
+	g.Seed = seed
+	g.rngSource = rand.NewPCG(seed, 0)
+	g.rng = rand.New(g.rngSource)
+	g.aiRNGSource = rand.NewPCG(seed, 1)
+	g.aiRNG = rand.New(g.aiRNGSource)
+}
+
+// advanceSimulation は前回の Update からの実経過時間を蓄積し、
+// 溜まった分だけ固定長のシミュレーションティックを進める
+func (g *Game) advanceSimulation() {
+	now := time.Now()
+	if !g.lastUpdateTime.IsZero() {
+		elapsed := now.Sub(g.lastUpdateTime)
+		if elapsed > maxSimulationCatchUp {
+			elapsed = maxSimulationCatchUp
+		}
+		if g.replay != nil && g.replay.fastForward {
+			elapsed *= replayFastForwardSpeed
+		}
+		g.simAccumulator += elapsed
+	}
+	g.lastUpdateTime = now
+	for g.simAccumulator >= simulationTickDuration {
+		if g.replay != nil && g.replay.atEnd(g.TickCount) {
+			g.replay.paused = true
+			g.stopSimulationClock()
+			return
+		}
+		g.simAccumulator -= simulationTickDuration
+		g.Step()
+		if g.State != StatePlaying {
+			// メッセージ表示などで時間が止まったら、残りの蓄積時間は持ち越さない
+			g.stopSimulationClock()
+			return
+		}
+	}
+}
+
+// stopSimulationClock は時間停止中の経過時間が再開後に一気に消化されないよう、蓄積をリセットする
+func (g *Game) stopSimulationClock() {
+	g.simAccumulator = 0
+	g.lastUpdateTime = time.Time{}
+}
+
+// Step はシミュレーションを1ティック進める。表示のフレームレート（TPS）には依存しない。
+func (g *Game) Step() {
+	if g.recordReplay && g.recorder == nil {
+		g.startRecording()
+	}
+	g.TickCount++
+	for _, m := range g.Medarots {
+		if m.State == StateIdle {
+			m.IdleTicks++
+		}
+	}
+	if g.BattleMode == ModeTurn {
+		g.updateTurnOrder()
+	} else {
+		g.updateProgress()
+	}
+	g.processReadyQueue()
+	g.processIdleMedarots()
+	g.checkGameEnd()
+}
+
+// showPendingActionModal は行動選択待ちのプレイヤーメダロットがいればモーダルを表示する
+func (g *Game) showPendingActionModal() {
+	if g.ui.actionModal == nil && g.playerMedarotToAct != nil && g.State != StateGameOver {
+		g.ui.ShowActionModal(g, g.playerMedarotToAct)
+	}
+}
+
+// finishPlayerSelection は行動選択モーダルを閉じ、ウェイト/ターン制で止めていた時間を再開する
+func (g *Game) finishPlayerSelection() {
+	if g.ui != nil {
+		g.ui.HideActionModal()
+	}
+	g.playerMedarotToAct = nil
+	// アクティブモードでは選択中にメッセージ表示へ遷移している場合があるので、その状態は上書きしない
+	if g.State == StatePlayerActionSelect {
+		g.State = StatePlaying
+	}
+}
+
+// ParseBattleMode はコマンドライン等で指定された文字列を BattleMode に変換する
+func ParseBattleMode(s string) (BattleMode, error) {
+	switch mode := BattleMode(s); mode {
+	case ModeActive, ModeWait, ModeTurn:
+		return mode, nil
+	}
+	return "", fmt.Errorf("不明なバトルモード: %q (active, wait, turn のいずれか)", s)
+}
+
 // [ADDED] getTargetCandidatesメソッドをラッパーとして復活
 func (g *Game) getTargetCandidates(actingMedarot *Medarot) []*Medarot {
 	// 内部でai.goの関数を呼び出す
@@ -109,8 +261,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		bf.DrawDebug(screen)
 	}
 	if g.DebugMode {
-		ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %0.2f\nFPS: %0.2f\nState: %s",
-			ebiten.ActualTPS(), ebiten.ActualFPS(), g.State))
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %0.2f\nFPS: %0.2f\nState: %s\nMode: %s\nTick: %d",
+			ebiten.ActualTPS(), ebiten.ActualFPS(), g.State, g.BattleMode, g.TickCount))
 	}
 }
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
@@ -126,6 +278,7 @@ func (g *Game) initializeMedarotLists() {
 		return g.sortedMedarotsForDraw[i].DrawIndex < g.sortedMedarotsForDraw[j].DrawIndex
 	})
 	for _, m := range g.Medarots {
+		m.logger = g.logger
 		if m.IsLeader {
 			if m.Team == Team1 {
 				g.team1Leader = m
@@ -150,50 +303,130 @@ func (g *Game) updateProgress() {
 			if m.State == StateCharging {
 				m.ChangeState(StateReady)
 				g.actionQueue = append(g.actionQueue, m)
-				log.Printf("%s のチャージが完了。実行キューに追加。", m.Name)
+				g.logger.Printf("%s のチャージが完了。実行キューに追加。", m.Name)
 			} else if m.State == StateCooldown {
 				m.ChangeState(StateIdle)
 			}
 		}
 	}
 }
+// updateTurnOrder はターン制モードで全員の行動選択が揃った時点で、
+// チャージ中のメダロットをイニシアチブ順に実行キューへ積む
+func (g *Game) updateTurnOrder() {
+	if len(g.actionQueue) > 0 || g.playerMedarotToAct != nil {
+		return
+	}
+	var selected []*Medarot
+	for _, m := range g.Medarots {
+		switch m.State {
+		case StateIdle:
+			if g.canSelectAction(m) {
+				return // まだ行動を選んでいないメダロットがいる
+			}
+		case StateCharging:
+			selected = append(selected, m)
+		}
+	}
+	if len(selected) == 0 {
+		return
+	}
+	// イニシアチブ: 推進力を加味したチャージ時間が短いほど先に行動し、同じなら推進力の高い方が先
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].TotalDuration != selected[j].TotalDuration {
+			return selected[i].TotalDuration < selected[j].TotalDuration
+		}
+		return selected[i].GetOverallPropulsion() > selected[j].GetOverallPropulsion()
+	})
+	for _, m := range selected {
+		m.ChangeState(StateReady)
+		g.actionQueue = append(g.actionQueue, m)
+	}
+	g.logger.Printf("ターン開始: %d体が行動します。", len(selected))
+}
+
+// canSelectAction はメダロットが待機中で、使えるパーツと攻撃対象の両方を持っているかを返す
+func (g *Game) canSelectAction(m *Medarot) bool {
+	return m.State == StateIdle && len(m.GetAvailableAttackParts()) > 0 && len(g.getTargetCandidates(m)) > 0
+}
+
 func (g *Game) processReadyQueue() {
 	if len(g.actionQueue) == 0 {
 		return
 	}
-	sort.SliceStable(g.actionQueue, func(i, j int) bool {
-		return g.actionQueue[i].GetOverallPropulsion() > g.actionQueue[j].GetOverallPropulsion()
-	})
+	// ターン制ではキューがすでにイニシアチブ順に並んでいる
+	if g.BattleMode != ModeTurn {
+		sort.SliceStable(g.actionQueue, func(i, j int) bool {
+			return g.actionQueue[i].GetOverallPropulsion() > g.actionQueue[j].GetOverallPropulsion()
+		})
+	}
 	for len(g.actionQueue) > 0 {
 		actingMedarot := g.actionQueue[0]
 		g.actionQueue = g.actionQueue[1:]
-		actingMedarot.ExecuteAction(&g.Config.Balance)
+		// キューに積まれた後（ターン制ではターンの途中）に頭部を壊されたメダロットは行動しない
+		if actingMedarot.State == StateBroken {
+			g.logger.Printf("%s は機能停止しているため行動しない。", actingMedarot.Name)
+			continue
+		}
+		actingMedarot.ExecuteAction(&g.Config.Balance, g.rng)
+		result := actingMedarot.LastActionResult
+		g.addBattleLog(LogKindAction, actingMedarot.Team, actingMedarot.LastActionLog, &result)
 		g.enqueueMessage(actingMedarot.LastActionLog, func() {
-			if actingMedarot.State != StateBroken {
-				actingMedarot.StartCooldown(&g.Config.Balance)
-			}
+			g.finishAction(actingMedarot)
 		})
 		return
 	}
 }
+
+// finishAction は行動を終えたメダロットを次の状態へ移す。
+// ターン制ではクールダウンを挟まず、次のターンの行動選択に戻る。
+func (g *Game) finishAction(m *Medarot) {
+	if m.State == StateBroken {
+		return
+	}
+	if g.BattleMode == ModeTurn {
+		m.ChangeState(StateIdle)
+		return
+	}
+	m.StartCooldown(&g.Config.Balance)
+}
+
 func (g *Game) processIdleMedarots() {
-	if g.playerMedarotToAct != nil || g.State != StatePlaying {
+	if g.State != StatePlaying {
+		return
+	}
+	// ターン制では、現在のターンの行動がすべて終わるまで次の行動選択を始めない
+	if g.BattleMode == ModeTurn && len(g.actionQueue) > 0 {
+		return
+	}
+	// リプレイ再生中は、両チームとも記録された行動決定だけで動かす
+	if g.replay != nil {
+		g.applyReplayDecisions()
 		return
 	}
 	for _, m := range g.Medarots {
-		if m.State == StateIdle && m.Team != g.PlayerTeam {
+		if m.State == StateIdle && (m.Team != g.PlayerTeam || g.isAutoBattle(m)) {
 			aiSelectAction(g, m)
 		}
 	}
+	// アクティブモードでは選択中に被弾して行動不能になることがある
+	if g.playerMedarotToAct != nil && (g.playerMedarotToAct.State != StateIdle || g.isAutoBattle(g.playerMedarotToAct)) {
+		g.finishPlayerSelection()
+	}
+	if g.playerMedarotToAct != nil {
+		return
+	}
 	nextPlayerMedarot := g.findNextIdlePlayerMedarot()
 	if nextPlayerMedarot != nil {
 		g.playerMedarotToAct = nextPlayerMedarot
-		g.State = StatePlayerActionSelect
+		// アクティブモードでは行動選択中も時間を止めない
+		if g.BattleMode != ModeActive {
+			g.State = StatePlayerActionSelect
+		}
 	}
 }
 func (g *Game) findNextIdlePlayerMedarot() *Medarot {
 	for _, m := range g.Medarots {
-		if m.Team == g.PlayerTeam && m.State == StateIdle {
+		if m.Team == g.PlayerTeam && m.State == StateIdle && !g.isAutoBattle(m) {
 			return m
 		}
 	}
@@ -215,21 +448,46 @@ func (g *Game) checkGameEnd() {
 		}
 	}
 	// チーム1リーダーの頭部が破壊されているか、またはチーム2が全滅した場合
+	// enqueueMessage は状態を StateMessage にするので、StateGameOver はメッセージの後に設定する
 if g.team1Leader.GetPart(PartSlotHead).IsBroken || team2Func == 0 {
     g.winner = Team2
-    g.State = StateGameOver
     // チーム1リーダーが機能停止したことをメッセージに追加するとより分かりやすい
-    g.enqueueMessage(fmt.Sprintf("%sが機能停止！ チーム2の勝利！", g.team1Leader.Name), nil)
+    g.enqueueMessage(T("battle.win", "leader", g.team1Leader.Name, "team", 2), nil)
+    g.addBattleLog(LogKindSystem, TeamNone, g.message, nil)
+    g.State = StateGameOver
 // チーム2リーダーの頭部が破壊されているか、またはチーム1が全滅した場合
 } else if g.team2Leader.GetPart(PartSlotHead).IsBroken || team1Func == 0 {
     g.winner = Team1
+    g.enqueueMessage(T("battle.win", "leader", g.team2Leader.Name, "team", 1), nil)
+    g.addBattleLog(LogKindSystem, TeamNone, g.message, nil)
     g.State = StateGameOver
-    g.enqueueMessage(fmt.Sprintf("%sが機能停止！ チーム1の勝利！", g.team2Leader.Name), nil)
 }
+	if g.State == StateGameOver {
+		g.finishRecording()
+	}
 }
 func (g *Game) enqueueMessage(msg string, callback func()) {
 	g.message = msg
 	g.postMessageCallback = callback
 	g.State = StateMessage
+	// リプレイ再生中や UI のないシミュレーションでは、クリックを待たずにすぐ閉じたものとして進める
+	if g.autoAcknowledge || g.ui == nil {
+		g.acknowledgeMessage()
+		return
+	}
 	g.ui.ShowMessageWindow(g)
 }
+
+// acknowledgeMessage はメッセージを閉じ、メッセージに紐付いた後処理を実行して時間を再開する
+func (g *Game) acknowledgeMessage() {
+	if g.ui != nil {
+		g.ui.HideMessageWindow()
+	}
+	if g.postMessageCallback != nil {
+		callback := g.postMessageCallback
+		g.postMessageCallback = nil
+		callback()
+	}
+	g.State = StatePlaying
+	g.processIdleMedarots()
+}
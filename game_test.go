@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+// newTurnTestBattle はターン制で、最初のターンの行動が2体以上キューに積まれるところまで進めたバトルを作る
+func newTurnTestBattle(t *testing.T) *Game {
+	t.Helper()
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestBattle(t, data, string(AINormal))
+	g.BattleMode = ModeTurn
+	for len(g.actionQueue) < 2 && g.State != StateGameOver && g.TickCount < 10000 {
+		g.Step()
+	}
+	if len(g.actionQueue) < 2 {
+		t.Fatal("行動の順番待ちが2体以上になりませんでした")
+	}
+	return g
+}
+
+// runTurn は今のターンの行動がすべて終わるまでバトルを進める
+func runTurn(g *Game) {
+	for len(g.actionQueue) > 0 && g.State != StateGameOver {
+		g.Step()
+	}
+}
+
+// TestBrokenActorSkipsQueuedAction はターンの途中で頭部を壊されたメダロットが、積まれていた行動をしないことを確かめる
+func TestBrokenActorSkipsQueuedAction(t *testing.T) {
+	g := newTurnTestBattle(t)
+	victim := g.actionQueue[len(g.actionQueue)-1]
+	head := victim.GetPart(PartSlotHead)
+	victim.applyDamage(head, head.Armor)
+	if victim.State != StateBroken {
+		t.Fatalf("頭部を壊しても %s が機能停止しません", victim.Name)
+	}
+	logCount := len(g.battleLog)
+	runTurn(g)
+	for _, entry := range g.battleLog[logCount:] {
+		if entry.Kind == LogKindAction && entry.Result != nil && entry.Result.ActorID == victim.ID {
+			t.Errorf("機能停止した %s が行動しました: %s", victim.Name, entry.Text)
+		}
+	}
+}
+
+// TestBrokenPartFailsQueuedAction はチャージ中に選んだパーツを壊されたメダロットが、攻撃せずに行動に失敗することを確かめる
+func TestBrokenPartFailsQueuedAction(t *testing.T) {
+	g := newTurnTestBattle(t)
+	actor := g.actionQueue[len(g.actionQueue)-1]
+	part := actor.GetPart(actor.SelectedPartKey)
+	if part.Type == PartTypeHead {
+		t.Skip("頭部のパーツを選んでいるので、壊すと機能停止になる")
+	}
+	actor.applyDamage(part, part.Armor)
+	logCount := len(g.battleLog)
+	runTurn(g)
+	found := false
+	for _, entry := range g.battleLog[logCount:] {
+		if entry.Kind != LogKindAction || entry.Result == nil || entry.Result.ActorID != actor.ID {
+			continue
+		}
+		found = true
+		if entry.Result.Executed {
+			t.Errorf("壊れた %s で攻撃しました: %s", part.PartName, entry.Text)
+		}
+	}
+	if !found {
+		t.Errorf("%s の行動がバトルログにありません", actor.Name)
+	}
+}
@@ -5,6 +5,7 @@ go 1.24.3
 require (
 	github.com/ebitenui/ebitenui v0.6.2
 	github.com/hajimehoshi/ebiten/v2 v2.8.8
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 開発モード (-dev) で、変更されたデータや設定をどう反映するか
+const (
+	// HotReloadLive は進行中のバトルのパーツ・メダルの数値とバランス設定をその場で差し替える
+	HotReloadLive = "live"
+	// HotReloadRestart は新しいデータと設定で同じシードのバトルを最初からやり直す
+	HotReloadRestart = "restart"
+)
+
+// hotReloadInterval はファイルの変更を調べる間隔
+const hotReloadInterval = time.Second
+
+// fileStamp はファイルが変わったかを見分けるための更新時刻と大きさ
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// hotReloader はデータパック（ディレクトリと zip）と設定ファイル・編成ファイルを定期的に調べ、変更があれば読み込み直す。
+// 埋め込みのデータは変わらないので調べない
+type hotReloader struct {
+	packSources []string
+	configPath  string
+	loadoutPath string
+	mode        string
+	stamps      map[string]fileStamp
+	lastCheck   time.Time
+}
+
+// newHotReloader は監視を始める。今のファイルの状態を基準にする
+func newHotReloader(packSources []string, configPath, loadoutPath, mode string) (*hotReloader, error) {
+	if mode != HotReloadLive && mode != HotReloadRestart {
+		return nil, fmt.Errorf("不明な反映方法です: %s (%s か %s)", mode, HotReloadLive, HotReloadRestart)
+	}
+	h := &hotReloader{packSources: packSources, configPath: configPath, loadoutPath: loadoutPath, mode: mode, lastCheck: time.Now()}
+	h.stamps = h.scan()
+	return h, nil
+}
+
+// scan は監視対象のファイルの状態を集める。無いファイルは含めない
+func (h *hotReloader) scan() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	paths := []string{h.configPath, h.loadoutPath}
+	for _, source := range h.packSources {
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			paths = append(paths, source) // zip ファイル（embedded は Stat できないので含まれない）
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				paths = append(paths, filepath.Join(source, entry.Name()))
+			}
+		}
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+// changed は前回から追加・削除・更新されたファイルがあるかを返す。調べるのは hotReloadInterval ごと
+func (h *hotReloader) changed() bool {
+	if time.Since(h.lastCheck) < hotReloadInterval {
+		return false
+	}
+	h.lastCheck = time.Now()
+	stamps := h.scan()
+	differs := len(stamps) != len(h.stamps)
+	for path, stamp := range stamps {
+		if old, ok := h.stamps[path]; !ok || old != stamp {
+			differs = true
+		}
+	}
+	h.stamps = stamps
+	return differs
+}
+
+// load はデータと設定を読み込み直して検証する。問題があれば1つずつ返す
+func (h *hotReloader) load() (*GameData, Config, []error) {
+	var problems []error
+	gameData, _, err := LoadDataPacks(h.packSources)
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		problems = append(problems, joined.Unwrap()...)
+	} else if err := ApplyLoadoutFile(gameData, h.loadoutPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		problems = append(problems, err)
+	} else {
+		problems = append(problems, ValidateGameData(gameData)...)
+	}
+	config, err := LoadConfig(h.configPath)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	// 言語は起動時のまま（-lang で上書きしていることもあるため）
+	config.UI.Language = currentLanguage
+	gameData.Localize(currentLanguage)
+	return gameData, config, problems
+}
+
+// updateHotReload はファイルが変わっていれば読み込み直して反映する。
+// 読み込みに失敗したときは今の状態のまま続け、トーストで知らせる。
+func (g *Game) updateHotReload() {
+	if g.hotReload == nil || g.replay != nil || !g.hotReload.changed() {
+		return
+	}
+	gameData, config, problems := g.hotReload.load()
+	if len(problems) > 0 {
+		log.Printf("データの再読み込みに失敗しました:\n%v", errors.Join(problems...))
+		msg := T("reload.failed", "error", problems[0])
+		if len(problems) > 1 {
+			msg = T("reload.failed_more", "error", problems[0], "count", len(problems)-1)
+		}
+		g.notifyHotReload(msg)
+		return
+	}
+	if g.hotReload.mode == HotReloadRestart {
+		g.restartBattle(gameData, config)
+		g.notifyHotReload(T("reload.restarted"))
+		return
+	}
+	g.applyLiveData(gameData, config)
+	g.notifyHotReload(T("reload.applied"))
+}
+
+// notifyHotReload は再読み込みの結果をトーストで知らせる（UI が無ければログだけに残す）
+func (g *Game) notifyHotReload(msg string) {
+	log.Println(msg)
+	if g.ui != nil {
+		g.ui.ShowToast(msg)
+	}
+}
+
+// applyLiveData は新しいデータの数値を進行中のバトルのパーツとメダルに反映する。
+// パーツは受けたダメージを保ったまま最大装甲を変え、壊れたパーツは壊れたままにする。
+// 画面の大きさなどレイアウトの設定はバトルをやり直すまで反映しない（色は反映する）。
+func (g *Game) applyLiveData(gameData *GameData, config Config) {
+	// 途中で数値が変わるので、ここまでのリプレイを書き出し、ここから記録し直す
+	g.finishRecording()
+	g.GameData = gameData
+	g.Config.Balance = config.Balance
+	g.Config.UI.Colors = config.UI.Colors
+	for _, m := range g.Medarots {
+		if medal := findMedalByID(gameData.Medals, m.Medal.ID); medal != nil {
+			*m.Medal = *medal
+		}
+		for _, part := range m.Parts {
+			latest, ok := gameData.AllParts[part.ID]
+			if !ok {
+				continue
+			}
+			damage := part.MaxArmor - part.Armor
+			updated := *latest
+			updated.MaxArmor = latest.Armor
+			updated.IsBroken = part.IsBroken
+			switch {
+			case part.IsBroken:
+				updated.Armor = 0
+			default:
+				updated.Armor = max(1, latest.Armor-damage)
+			}
+			*part = updated
+		}
+	}
+	g.rebuildUI()
+}
+
+// restartBattle は新しいデータと設定で、同じシード・同じバトルモードのバトルを最初から始める
+func (g *Game) restartBattle(gameData *GameData, config Config) {
+	g.finishRecording()
+	g.GameData = gameData
+	g.Config = config
+	g.SetSeed(g.Seed)
+	g.Medarots = InitializeAllMedarots(gameData, g.logger)
+	g.TickCount = 0
+	g.State = StatePlaying
+	g.actionQueue = make([]*Medarot, 0)
+	g.message = ""
+	g.postMessageCallback = nil
+	g.winner = TeamNone
+	g.playerMedarotToAct = nil
+	g.battleLog = nil
+	g.stopSimulationClock()
+	g.initializeMedarotLists()
+	g.rebuildUI()
+}
+
+// rebuildUI は UI を作り直す（UI はメダロットを参照しているため）。表示中のメッセージは出し直す
+func (g *Game) rebuildUI() {
+	if g.ui == nil {
+		return
+	}
+	g.ui = NewUI(g)
+	if g.State == StateMessage {
+		g.ui.ShowMessageWindow(g)
+	}
+}
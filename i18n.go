@@ -0,0 +1,191 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// プレイヤーに見せる文字列の言語
+const (
+	LangJapanese = "ja"
+	LangEnglish  = "en"
+)
+
+// defaultLanguage はメッセージが見つからないときに使う言語（カタログの基準）
+const defaultLanguage = LangJapanese
+
+// nameLanguages はデータファイルに別の言語の名前の列（name_en など）を持てる言語。日本語は元の列を使う
+var nameLanguages = []string{LangEnglish}
+
+// localeFiles は言語ごとのメッセージカタログ (locales/<言語>.json)。キーとメッセージの対応を書く
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs は言語ごとのメッセージ。埋め込みのファイルなので、読めなければビルドの誤りとして起動時に止める
+var catalogs = loadCatalogs()
+
+// currentLanguage は今の言語。SetLanguage で切り替える
+var currentLanguage = defaultLanguage
+
+// placeholderPattern はメッセージの中の {名前} の置き換え位置
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// loadCatalogs は埋め込みのメッセージカタログをすべて読む
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	result := make(map[string]map[string]string)
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("locales/%s の解析に失敗: %v", entry.Name(), err))
+		}
+		result[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return result
+}
+
+// Languages は使える言語を返す
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// SetLanguage はプレイヤーに見せる文字列の言語を切り替える。カタログに足りないメッセージがあればログに出す（日本語で代わりに表示する）
+func SetLanguage(lang string) error {
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("不明な言語です: %s (%s のいずれか)", lang, strings.Join(Languages(), ", "))
+	}
+	currentLanguage = lang
+	for _, problem := range checkCatalog(lang) {
+		log.Printf("locales/%s.json: %v", lang, problem)
+	}
+	return nil
+}
+
+// checkCatalog は lang のカタログを基準の言語と比べ、足りないメッセージと置き換え位置の食い違いを返す
+func checkCatalog(lang string) []error {
+	var problems []error
+	base, messages := catalogs[defaultLanguage], catalogs[lang]
+	keys := make([]string, 0, len(base))
+	for key := range base {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		msg, ok := messages[key]
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s がありません", key))
+			continue
+		}
+		want := placeholderPattern.FindAllString(base[key], -1)
+		got := placeholderPattern.FindAllString(msg, -1)
+		sort.Strings(want)
+		sort.Strings(got)
+		if strings.Join(want, "") != strings.Join(got, "") {
+			problems = append(problems, fmt.Errorf("%s の置き換え位置 %v が %s.json の %v と合いません", key, got, defaultLanguage, want))
+		}
+	}
+	return problems
+}
+
+// T は今の言語のメッセージを返す。args は "名前", 値 の組で、メッセージの {名前} を値に置き換える。
+// 今の言語に無いメッセージは日本語で、どちらにも無ければキーをそのまま返す
+func T(key string, args ...any) string {
+	msg, ok := catalogs[currentLanguage][key]
+	if !ok {
+		msg, ok = catalogs[defaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		msg = strings.ReplaceAll(msg, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return msg
+}
+
+// localizedColumns は column に対する別の言語の名前の列 (column_en など) を返す
+func localizedColumns(column string) []dataColumn {
+	var columns []dataColumn
+	for _, lang := range nameLanguages {
+		columns = append(columns, dataColumn{Name: column + "_" + lang, Since: 2})
+	}
+	return columns
+}
+
+// localizedNames は別の言語の名前の列を読む。空の列は含めない
+func (r *dataRecord) localizedNames(column string) map[string]string {
+	var names map[string]string
+	for _, lang := range nameLanguages {
+		if name := r.str(column + "_" + lang); name != "" && name != noneValue {
+			if names == nil {
+				names = make(map[string]string)
+			}
+			names[lang] = name
+		}
+	}
+	return names
+}
+
+// Localize はメダル・パーツ・メダロットの名前を lang の名前に置き換える。その言語の名前が無ければ元の名前のまま
+func (d *GameData) Localize(lang string) {
+	for i := range d.Medals {
+		if name, ok := d.Medals[i].Names[lang]; ok {
+			d.Medals[i].Name = name
+		}
+	}
+	for _, part := range d.AllParts {
+		if name, ok := part.Names[lang]; ok {
+			part.PartName = name
+		}
+	}
+	for i := range d.Medarots {
+		m := &d.Medarots[i]
+		if name, ok := m.Names[lang]; ok {
+			if _, kept := m.Names[defaultLanguage]; !kept {
+				m.Names[defaultLanguage] = m.Name // 編成ファイルには元の名前を書くので残しておく
+			}
+			m.Name = name
+		}
+	}
+}
+
+// Label はステートの表示名を返す（MedarotState の値はセーブデータにも残るので、表示だけを翻訳する）
+func (s MedarotState) Label() string {
+	switch s {
+	case StateIdle:
+		return T("state.idle")
+	case StateCharging:
+		return T("state.charging")
+	case StateReady:
+		return T("state.ready")
+	case StateCooldown:
+		return T("state.cooldown")
+	case StateBroken:
+		return T("state.broken")
+	}
+	return string(s)
+}
+
+// teamLabel はチームの表示名を返す
+func teamLabel(team TeamID) string {
+	return T("team.label", "team", int(team)+1)
+}
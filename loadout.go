@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// defaultLoadoutPath は編成画面で保存する編成ファイル。medarots.csv と同じ形式で、プレイヤーのチームの行だけを書く
+const defaultLoadoutPath = "loadout.csv"
+
+// ApplyLoadoutFile は編成ファイルの行で、同じIDのメダロットの編成を置き換える。
+// ファイルが無ければ fs.ErrNotExist を包んだエラーを返す。置き換えた結果に問題があれば、データは変えずにエラーを返す
+func ApplyLoadoutFile(gameData *GameData, path string) error {
+	loadouts, err := LoadMedarotLoadouts(fileSource(path))
+	if err != nil {
+		return err
+	}
+	medarots, err := mergeLoadouts(gameData, loadouts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	gameData.Medarots = medarots
+	return nil
+}
+
+// ApplyLoadouts は編成を差し替え、同じシード・同じバトルモードでバトルを最初からやり直す。
+// 差し替えた結果に問題があれば、データもバトルも変えずにエラーを返す。リプレイの再生中は編成を変えられない
+func (g *Game) ApplyLoadouts(loadouts []MedarotData) error {
+	if g.replay != nil {
+		return fmt.Errorf("リプレイの再生中は編成を変えられません")
+	}
+	medarots, err := mergeLoadouts(g.GameData, loadouts)
+	if err != nil {
+		return err
+	}
+	g.GameData.Medarots = medarots
+	g.restartBattle(g.GameData, g.Config)
+	return nil
+}
+
+// mergeLoadouts は gameData のメダロットのうち loadouts と同じIDのものを置き換えた一覧を返す（gameData は変えない）。
+// 知らないIDがあるか、置き換えた結果が ValidateGameData を通らなければエラーを返す
+func mergeLoadouts(gameData *GameData, loadouts []MedarotData) ([]MedarotData, error) {
+	medarots := append([]MedarotData(nil), gameData.Medarots...)
+	for _, loadout := range loadouts {
+		i := loadoutIndex(medarots, loadout.ID)
+		if i < 0 {
+			return nil, fmt.Errorf("%s はデータに無いメダロットです", loadout.ID)
+		}
+		medarots[i] = loadout
+	}
+	merged := *gameData
+	merged.Medarots = medarots
+	if problems := ValidateGameData(&merged); len(problems) > 0 {
+		return nil, fmt.Errorf("編成に問題があります:\n%w", errors.Join(problems...))
+	}
+	return medarots, nil
+}
+
+// SaveLoadoutFile は編成を編成ファイルに書き出す。形式は拡張子で決める
+func SaveLoadoutFile(path string, loadouts []MedarotData) error {
+	if err := writeDataFile(path, medarotColumns, medarotRecords(loadouts)); err != nil {
+		return fmt.Errorf("編成ファイルの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// loadoutIndex は id のメダロットの位置を返す。無ければ -1
+func loadoutIndex(medarots []MedarotData, id string) int {
+	for i := range medarots {
+		if medarots[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// teamLoadouts は team の編成を draw_index の順に返す（コピーなので書き換えてもデータは変わらない）
+func teamLoadouts(gameData *GameData, team TeamID) []MedarotData {
+	var loadouts []MedarotData
+	for _, m := range gameData.Medarots {
+		if m.Team == team {
+			loadouts = append(loadouts, m)
+		}
+	}
+	sort.SliceStable(loadouts, func(i, j int) bool { return loadouts[i].DrawIndex < loadouts[j].DrawIndex })
+	return loadouts
+}
+
+// loadoutStats は編成画面に出す、編成1体分の能力値のまとめ
+type loadoutStats struct {
+	Armor      int                     // 全パーツの装甲の合計
+	Propulsion int                     // 脚部の推進力
+	Mobility   int                     // 脚部の機動力
+	Charge     map[PartSlotKey]float64 // 攻撃パーツごとのチャージ時間（秒、推進力を加味）
+	Parts      map[PartSlotKey]*Part   // スロットごとのパーツ
+}
+
+// computeLoadoutStats は編成のパーツから能力値をまとめる。チャージ時間はバトルと同じ計算で求める
+func computeLoadoutStats(gameData *GameData, loadout MedarotData, balance *BalanceConfig) loadoutStats {
+	m := newMedarotFromLoadout(gameData, loadout, log.Default())
+	stats := loadoutStats{
+		Propulsion: m.GetOverallPropulsion(),
+		Mobility:   m.GetOverallMobility(),
+		Charge:     make(map[PartSlotKey]float64),
+		Parts:      m.Parts,
+	}
+	for slot, part := range m.Parts {
+		stats.Armor += part.Armor
+		if slot != PartSlotLegs {
+			stats.Charge[slot] = m.chargeTicks(part, balance) / SimulationTicksPerSecond
+		}
+	}
+	return stats
+}
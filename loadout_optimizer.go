@@ -0,0 +1,442 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strings"
+)
+
+// loadoutGene はメダロット1体の遺伝子の位置（メダル・頭・右腕・左腕・脚）
+type loadoutGene int
+
+const (
+	geneMedal loadoutGene = iota
+	geneHead
+	geneRightArm
+	geneLeftArm
+	geneLegs
+	geneCount
+)
+
+func (g loadoutGene) get(m *MedarotData) string {
+	switch g {
+	case geneMedal:
+		return m.MedalID
+	case geneHead:
+		return m.HeadID
+	case geneRightArm:
+		return m.RightArmID
+	case geneLeftArm:
+		return m.LeftArmID
+	}
+	return m.LegsID
+}
+
+func (g loadoutGene) set(m *MedarotData, id string) {
+	switch g {
+	case geneMedal:
+		m.MedalID = id
+	case geneHead:
+		m.HeadID = id
+	case geneRightArm:
+		m.RightArmID = id
+	case geneLeftArm:
+		m.LeftArmID = id
+	default:
+		m.LegsID = id
+	}
+}
+
+// loadoutPool は遺伝子の位置ごとに選べるメダルとパーツのID
+type loadoutPool [geneCount][]string
+
+// newLoadoutPool は medals.csv と parts.csv から、位置ごとの候補を集める
+func newLoadoutPool(gameData *GameData) (*loadoutPool, error) {
+	var pool loadoutPool
+	for _, medal := range gameData.Medals {
+		pool[geneMedal] = append(pool[geneMedal], medal.ID)
+	}
+	slotOf := map[PartType]loadoutGene{
+		PartTypeHead: geneHead,
+		PartTypeRArm: geneRightArm,
+		PartTypeLArm: geneLeftArm,
+		PartTypeLegs: geneLegs,
+	}
+	for id, part := range gameData.AllParts {
+		if gene, ok := slotOf[part.Type]; ok {
+			pool[gene] = append(pool[gene], id)
+		}
+	}
+	for gene := range pool {
+		if len(pool[gene]) == 0 {
+			return nil, errors.New(T("loadout.no_candidates", "slot", loadoutGene(gene).label()))
+		}
+		sort.Strings(pool[gene]) // map の順序に左右されないようにする
+	}
+	return &pool, nil
+}
+
+// loadoutSlotLabelKeys は遺伝子の位置の表示名のメッセージキー (loadout.slot.*)
+var loadoutSlotLabelKeys = [geneCount]string{"medal", "head", "r_arm", "l_arm", "legs"}
+
+// label は遺伝子の位置の、今の言語での表示名を返す
+func (g loadoutGene) label() string {
+	return T("loadout.slot." + loadoutSlotLabelKeys[g])
+}
+
+// evolvedTeam は進化の個体。1チーム分の編成と、評価済みならその適応度を持つ
+type evolvedTeam struct {
+	Members []MedarotData
+	Fitness float64
+}
+
+// key は同じ編成の評価を使い回すための文字列
+func (t *evolvedTeam) key() string {
+	var b strings.Builder
+	for _, m := range t.Members {
+		for gene := loadoutGene(0); gene < geneCount; gene++ {
+			b.WriteString(gene.get(&m))
+			b.WriteByte(',')
+		}
+		b.WriteByte('/')
+	}
+	return b.String()
+}
+
+// loadoutOptimizer は、ヘッドレスのバトルでの勝率を適応度にして編成を進化させる
+type loadoutOptimizer struct {
+	sim        SimulationOptions // Team1 以外の設定（AI・シード・回数など）
+	opponents  [][]MedarotData   // 対戦相手の編成（複数あれば総当たりの平均）
+	pool       *loadoutPool
+	rng        *rand.Rand
+	mutation   float64 // 遺伝子ごとの突然変異の確率
+	evaluated  map[string]float64
+	evaluation []*evolvedTeam // 評価した全編成（組み合わせの分析に使う）
+}
+
+// fitness は相手ごとの勝率（引き分けは0.5勝）の平均。すべての編成を同じシードで戦わせるので、編成の差で比べられる。
+func (o *loadoutOptimizer) fitness(team *evolvedTeam) (float64, error) {
+	if f, ok := o.evaluated[team.key()]; ok {
+		return f, nil
+	}
+	total := 0.0
+	for _, opponent := range o.opponents {
+		opts := o.sim
+		opts.Team1 = team.Members
+		opts.Team2 = opponent
+		report, err := RunSimulations(opts)
+		if err != nil {
+			return 0, err
+		}
+		total += (float64(report.Team1Wins) + float64(report.Draws)/2) / float64(report.Battles)
+	}
+	f := total / float64(len(o.opponents))
+	o.evaluated[team.key()] = f
+	o.evaluation = append(o.evaluation, &evolvedTeam{Members: team.Members, Fitness: f})
+	return f, nil
+}
+
+// newEvolvedMember はチームの i 番目（0始まり）の枠を作る。バトルログや作戦の割り当て (findMedarotByID) で
+// 区別できるよう、ID と名前は枠ごとに決まったものにする
+func newEvolvedMember(i int) MedarotData {
+	return MedarotData{ID: fmt.Sprintf("EVO-%02d", i+1), Name: fmt.Sprintf("候補%d", i+1)}
+}
+
+// randomTeam は候補から無作為に選んだ編成を作る
+func (o *loadoutOptimizer) randomTeam() *evolvedTeam {
+	team := &evolvedTeam{}
+	for i := 0; i < PlayersPerTeam; i++ {
+		m := newEvolvedMember(i)
+		for gene := loadoutGene(0); gene < geneCount; gene++ {
+			candidates := o.pool[gene]
+			gene.set(&m, candidates[o.rng.IntN(len(candidates))])
+		}
+		team.Members = append(team.Members, m)
+	}
+	return team
+}
+
+// crossover は2つの親から、遺伝子ごとにどちらかを受け継いだ子を作る（一様交叉）。
+// 一定の確率で遺伝子を候補の中から選び直す（突然変異）。
+func (o *loadoutOptimizer) crossover(a, b *evolvedTeam) *evolvedTeam {
+	child := &evolvedTeam{Members: make([]MedarotData, len(a.Members))}
+	for i := range child.Members {
+		child.Members[i] = newEvolvedMember(i)
+		for gene := loadoutGene(0); gene < geneCount; gene++ {
+			id := gene.get(&a.Members[i])
+			if o.rng.IntN(2) == 0 {
+				id = gene.get(&b.Members[i])
+			}
+			if o.rng.Float64() < o.mutation {
+				candidates := o.pool[gene]
+				id = candidates[o.rng.IntN(len(candidates))]
+			}
+			gene.set(&child.Members[i], id)
+		}
+	}
+	return child
+}
+
+// tournament は無作為に選んだ3体のうち最も適応度の高い個体を返す
+func (o *loadoutOptimizer) tournament(population []*evolvedTeam) *evolvedTeam {
+	best := population[o.rng.IntN(len(population))]
+	for i := 1; i < 3; i++ {
+		if c := population[o.rng.IntN(len(population))]; c.Fitness > best.Fitness {
+			best = c
+		}
+	}
+	return best
+}
+
+// evolve は初期集団（指定があれば seeds を含む）から世代を重ね、適応度の高い順に並べた最終世代を返す。
+// 上位 elite 体はそのまま次の世代に残す。
+func (o *loadoutOptimizer) evolve(seeds []*evolvedTeam, populationSize, generations, elite int, progress io.Writer) ([]*evolvedTeam, error) {
+	population := append([]*evolvedTeam(nil), seeds...)
+	for len(population) < populationSize {
+		population = append(population, o.randomTeam())
+	}
+	evaluate := func() error {
+		for _, team := range population {
+			f, err := o.fitness(team)
+			if err != nil {
+				return err
+			}
+			team.Fitness = f
+		}
+		sort.SliceStable(population, func(i, j int) bool { return population[i].Fitness > population[j].Fitness })
+		return nil
+	}
+	if err := evaluate(); err != nil {
+		return nil, err
+	}
+	for gen := 1; gen <= generations; gen++ {
+		next := append([]*evolvedTeam(nil), population[:min(elite, len(population))]...)
+		for len(next) < populationSize {
+			next = append(next, o.crossover(o.tournament(population), o.tournament(population)))
+		}
+		population = next
+		if err := evaluate(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(progress, "第%d世代: 最高 %.1f%%  評価済みの編成 %d\n", gen, population[0].Fitness*100, len(o.evaluated))
+	}
+	return population, nil
+}
+
+// ComboStats は、評価したすべての編成のうち、ある組み合わせ（同じメダロットに載ったメダルやパーツ2つ）を含むものの成績
+type ComboStats struct {
+	IDs        [2]string
+	Teams      int     // この組み合わせを含んだ編成の数
+	AvgFitness float64 // それらの編成の平均勝率
+	Lift       float64 // 全編成の平均勝率との差
+}
+
+// analyzeCombos は評価した編成から、勝率を押し上げている組み合わせを探す。
+// minTeams 未満の編成にしか現れない組み合わせは偶然の影響が大きいので除く。
+func (o *loadoutOptimizer) analyzeCombos(minTeams int) []ComboStats {
+	if len(o.evaluation) == 0 {
+		return nil
+	}
+	type acc struct {
+		teams int
+		sum   float64
+	}
+	combos := make(map[[2]string]*acc)
+	overall := 0.0
+	for _, team := range o.evaluation {
+		overall += team.Fitness
+		seen := make(map[[2]string]bool) // 同じ編成の中で重複して数えない
+		for _, m := range team.Members {
+			for g1 := loadoutGene(0); g1 < geneCount; g1++ {
+				for g2 := g1 + 1; g2 < geneCount; g2++ {
+					key := [2]string{g1.get(&m), g2.get(&m)}
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					if combos[key] == nil {
+						combos[key] = &acc{}
+					}
+					combos[key].teams++
+					combos[key].sum += team.Fitness
+				}
+			}
+		}
+	}
+	overall /= float64(len(o.evaluation))
+
+	var stats []ComboStats
+	for ids, a := range combos {
+		if a.teams < minTeams {
+			continue
+		}
+		avg := a.sum / float64(a.teams)
+		stats = append(stats, ComboStats{IDs: ids, Teams: a.teams, AvgFitness: avg, Lift: avg - overall})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Lift != stats[j].Lift {
+			return stats[i].Lift > stats[j].Lift
+		}
+		return stats[i].IDs[0]+stats[i].IDs[1] < stats[j].IDs[0]+stats[j].IDs[1]
+	})
+	return stats
+}
+
+// runMedaevo は "medaevo" サブコマンド。パーツとメダルの組み合わせを進化的に探索し、
+// 最も強い編成を medarots.csv と同じ形式で書き出し、強すぎる組み合わせを報告する。
+//
+//	medarot-ebiten medaevo -vs "E-01,E-02,E-03;P-01,P-02,P-03" -generations 30 -out evolved_medarots.csv
+func runMedaevo(args []string) error {
+	fs := flag.NewFlagSet("medaevo", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "既定値に重ねる設定ファイル (JSON)")
+	dataDir := fs.String("data", "", "標準データの代わりに読むデータのディレクトリか zip (省略時は埋め込みのデータ)")
+	packs := fs.String("packs", "", "標準データの上に重ねるデータパックのディレクトリか zip (カンマ区切り、後ろほど優先)")
+	vs := fs.String("vs", "", "対戦相手のメダロットID（カンマ区切り、先頭がリーダー）。; で区切ると複数の相手と総当たりする。省略時は medarots.csv のチーム1")
+	battles := fs.Int("n", 20, "1つの編成を1つの相手と戦わせる回数")
+	population := fs.Int("population", 24, "1世代の編成の数")
+	generations := fs.Int("generations", 20, "世代の数")
+	elite := fs.Int("elite", 2, "そのまま次の世代に残す上位の編成の数")
+	mutation := fs.Float64("mutation", 0.1, "メダルやパーツ1つごとの突然変異の確率")
+	seedTeam := fs.Bool("seedteam", true, "medarots.csv のチーム0を初期集団に入れる")
+	seed := fs.Uint64("seed", 1, "バトルと探索のシード")
+	ai1 := fs.String("ai1", string(AINormal), "探索する側のAI")
+	ai2 := fs.String("ai2", string(AINormal), "対戦相手のAI")
+	mode := fs.String("mode", string(ModeWait), "バトルモード (active, wait, turn)")
+	top := fs.Int("top", 3, "報告する編成の数")
+	combos := fs.Int("combos", 10, "報告する組み合わせの数")
+	out := fs.String("out", "evolved_medarots.csv", "1位の編成と対戦相手の出力先 (medarots.csv と同じ形式)")
+	fs.Parse(args)
+
+	battleMode, err := ParseBattleMode(*mode)
+	if err != nil {
+		return err
+	}
+	if *population < 2 || *generations < 0 || *elite < 0 || *elite >= *population {
+		return fmt.Errorf("-population は2以上、-elite は0以上 -population 未満にしてください")
+	}
+	gameData, _, err := LoadDataPacks(PackSources(*dataDir, *packs))
+	if err != nil {
+		return err
+	}
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	pool, err := newLoadoutPool(gameData)
+	if err != nil {
+		return err
+	}
+	o := &loadoutOptimizer{
+		sim: SimulationOptions{
+			GameData: gameData,
+			Balance:  config.Balance,
+			AI1:      *ai1,
+			AI2:      *ai2,
+			Mode:     battleMode,
+			Seed:     *seed,
+			Battles:  *battles,
+		},
+		pool:      pool,
+		rng:       rand.New(rand.NewPCG(*seed, 3)),
+		mutation:  *mutation,
+		evaluated: make(map[string]float64),
+	}
+	for _, ids := range strings.Split(*vs, ";") {
+		opponent, err := selectLoadouts(gameData, ids, Team2)
+		if err != nil {
+			return err
+		}
+		o.opponents = append(o.opponents, opponent)
+	}
+	var seeds []*evolvedTeam
+	if *seedTeam {
+		baseline, err := selectLoadouts(gameData, "", Team1)
+		if err != nil {
+			return err
+		}
+		if len(baseline) == PlayersPerTeam {
+			seeds = append(seeds, &evolvedTeam{Members: baseline})
+		}
+	}
+
+	final, err := o.evolve(seeds, *population, *generations, *elite, os.Stderr)
+	if err != nil {
+		return err
+	}
+	best := uniqueTeams(final, *top)
+	if err := writeEvolvedTeams(*out, best[0], o.opponents[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("上位の編成 (相手 %d チームとの平均勝率)\n", len(o.opponents))
+	for rank, team := range best {
+		fmt.Printf("  %d位 %.1f%%\n", rank+1, team.Fitness*100)
+		for _, m := range team.Members {
+			fmt.Printf("      %s\n", describeLoadout(gameData, &m))
+		}
+	}
+	fmt.Printf("\n強すぎる可能性のある組み合わせ (評価した %d 編成のうち、含む編成の平均勝率が高い順)\n", len(o.evaluation))
+	fmt.Printf("  %8s %8s %6s  %s\n", "平均勝率", "差", "編成数", "組み合わせ")
+	for i, c := range o.analyzeCombos(max(3, len(o.evaluation)/50)) {
+		if i >= *combos {
+			break
+		}
+		fmt.Printf("  %7.1f%% %+7.1f%% %6d  %s + %s\n", c.AvgFitness*100, c.Lift*100, c.Teams, loadoutItemName(gameData, c.IDs[0]), loadoutItemName(gameData, c.IDs[1]))
+	}
+	fmt.Printf("\n1位の編成と対戦相手を %s に書き出しました。\n", *out)
+	return nil
+}
+
+// uniqueTeams は適応度順の集団から、同じ編成を除いて上位 n 個を返す
+func uniqueTeams(population []*evolvedTeam, n int) []*evolvedTeam {
+	var teams []*evolvedTeam
+	seen := make(map[string]bool)
+	for _, team := range population {
+		if len(teams) >= n {
+			break
+		}
+		if seen[team.key()] {
+			continue
+		}
+		seen[team.key()] = true
+		teams = append(teams, team)
+	}
+	return teams
+}
+
+// writeEvolvedTeams は最も強い編成をチーム0、対戦相手をチーム1として medarots.csv と同じ形式で書き出す（拡張子で形式を決める）。
+// data/medarots.csv と差し替えて -data data で起動すれば、そのまま見つかった編成で遊べる。
+func writeEvolvedTeams(path string, best *evolvedTeam, opponent []MedarotData) error {
+	records := medarotRecords(arrangeTeams(best.Members, opponent))
+	if err := writeDataFile(path, medarotColumns, records); err != nil {
+		return fmt.Errorf("編成ファイルの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// describeLoadout は編成1体分を「メダル / 頭 / 右腕 / 左腕 / 脚」の名前で表す
+func describeLoadout(gameData *GameData, m *MedarotData) string {
+	var names []string
+	for gene := loadoutGene(0); gene < geneCount; gene++ {
+		names = append(names, loadoutItemName(gameData, gene.get(m)))
+	}
+	return strings.Join(names, " / ")
+}
+
+// loadoutItemName はメダルまたはパーツのIDを「名前(ID)」にする
+func loadoutItemName(gameData *GameData, id string) string {
+	if part, ok := gameData.AllParts[id]; ok {
+		return fmt.Sprintf("%s(%s)", part.PartName, id)
+	}
+	for _, medal := range gameData.Medals {
+		if medal.ID == id {
+			return fmt.Sprintf("%s(%s)", medal.Name, id)
+		}
+	}
+	return id
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestNewLoadoutPoolErrorIsLocalized は候補の無い部位のエラーが、プレイヤーの言語で表示されることを確かめる
+func TestNewLoadoutPoolErrorIsLocalized(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLanguage("en"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetLanguage(defaultLanguage) })
+
+	empty := *data
+	empty.Medals = nil
+	_, err = newLoadoutPool(&empty)
+	if err == nil || err.Error() != "No candidates for Medal" {
+		t.Errorf("newLoadoutPool のエラー = %v", err)
+	}
+}
+
+// TestEvolvedMembersHavePlaceholderIDs は進化の個体のメダロットに、枠ごとに決まった ID と名前が付くことを確かめる
+func TestEvolvedMembersHavePlaceholderIDs(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := newLoadoutPool(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &loadoutOptimizer{pool: pool, rng: rand.New(rand.NewPCG(1, 2)), mutation: 0.5}
+	a, b := o.randomTeam(), o.randomTeam()
+	for _, team := range []*evolvedTeam{a, b, o.crossover(a, b)} {
+		for i, m := range team.Members {
+			want := newEvolvedMember(i)
+			if m.ID != want.ID || m.Name != want.Name {
+				t.Errorf("%d 番目のメダロット = %q/%q, want %q/%q", i, m.ID, m.Name, want.ID, want.Name)
+			}
+		}
+	}
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"bytes"
 	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
-	"math/rand"
 	"os"
-	"time"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
@@ -31,13 +35,74 @@ func loadFont() (text.Face, error) {
 }
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	// サブコマンド: ウィンドウを開かずに動くツール
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "medasim":
+			run = runMedasim
+		case "medatune":
+			run = runMedatune
+		case "medaevo":
+			run = runMedaevo
+		case "medadata":
+			run = runMedadata
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	modeFlag := flag.String("mode", string(ModeWait), "バトルモード (active: 選択中も時間が進む, wait: 選択中は時間停止, turn: ターン制)")
+	seedFlag := flag.Uint64("seed", 0, "バトルの乱数シード (0 なら現在時刻から決める)")
+	loadFlag := flag.String("load", "", "起動時に再開するセーブデータのパス")
+	saveFileFlag := flag.String("savefile", "savegame.json", "メニューからのセーブ/ロードに使うファイル")
+	replayFlag := flag.String("replay", "", "再生するリプレイファイルのパス")
+	replayDirFlag := flag.String("replaydir", "replays", "バトル終了時にリプレイを保存するディレクトリ")
+	logDirFlag := flag.String("logdir", "logs", "バトルログの書き出し先ディレクトリ")
+	ai1Flag := flag.String("ai1", string(AINormal), "チーム1をAIに任せる場合の難易度 (easy, normal, hard, expert, montecarlo, team)")
+	ai2Flag := flag.String("ai2", string(AINormal), "チーム2（敵チーム）のAIの難易度 (easy, normal, hard, expert, montecarlo, team)")
+	bot1Flag := flag.String("bot1", "", "チーム1の行動を決める外部ボットのコマンド (例: \"python bot.py\")。指定するとチーム1は全員おまかせになる")
+	bot2Flag := flag.String("bot2", "", "チーム2の行動を決める外部ボットのコマンド")
+	botTimeoutFlag := flag.Duration("bottimeout", defaultBotTimeout, "外部ボットの返事を待つ時間。過ぎたら -ai1/-ai2 のAIで代わりに決める")
+	aiRulesFlag := flag.String("airules", "", "メダロットごとの行動ルールを書いたファイル。省略時はデータの ai_rules.csv（無ければ使わない）")
+	validateAIFlag := flag.Bool("validateai", false, "行動ルールファイルを検証して結果を表示し、終了する")
+	autoFlag := flag.Bool("auto", false, "チーム1を最初から全員おまかせ（-ai1 のAI）にする")
+	configFlag := flag.String("config", defaultConfigPath, "既定値に重ねる設定ファイル (JSON)。無ければ既定値を使う")
+	dataFlag := flag.String("data", "", "標準データの代わりに読むデータのディレクトリか zip。省略時はバイナリに埋め込んだデータを使う")
+	packsFlag := flag.String("packs", "", "標準データの上に重ねるデータパック（MOD）のディレクトリか zip (カンマ区切り、後ろほど優先)")
+	devFlag := flag.Bool("dev", false, "開発モード: -data・-packs と設定ファイルの変更を検知して読み込み直す（埋め込みのデータは変わらないので -data data と併せて使う）")
+	devApplyFlag := flag.String("devapply", HotReloadLive, "開発モードで変更を反映する方法 (live: 進行中のバトルに反映, restart: バトルをやり直す)")
+	loadoutFlag := flag.String("loadout", defaultLoadoutPath, "編成画面で保存する編成ファイル。あれば起動時にプレイヤーのチームの編成をこれで置き換える")
+	setupFlag := flag.Bool("setup", false, "起動時に編成画面を開く（メニューの「編成」と同じ）")
+	langFlag := flag.String("lang", "", "表示する言語 (ja, en)。省略時は設定ファイルの UI.Language")
+	dumpConfigFlag := flag.Bool("dumpconfig", false, "既定の設定を JSON で表示して終了する（設定ファイルのひな形）")
+	tpsFlag := flag.Int("tps", ebiten.DefaultTPS, "表示側の更新レート。シミュレーション速度には影響しない")
+	flag.Parse()
 
-	wd, err := os.Getwd()
+	if *dumpConfigFlag {
+		data, err := json.MarshalIndent(DefaultConfig(), "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	battleMode, err := ParseBattleMode(*modeFlag)
 	if err != nil {
-		log.Printf("Failed to get current working directory: %v", err)
-	} else {
-		log.Printf("Current working directory: %s", wd)
+		log.Fatal(err)
+	}
+	team1AI, err := NewTeamAI(*ai1Flag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	team2AI, err := NewTeamAI(*ai2Flag)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	fontFace, err := loadFont()
@@ -45,26 +110,155 @@ func main() {
 		log.Fatalf("フォントの読み込みに失敗しました: %v", err)
 	}
 
-	gameData, err := LoadAllGameData()
+	gameData, packReport, err := LoadDataPacks(PackSources(*dataFlag, *packsFlag))
 	if err != nil {
 		log.Fatalf("Failed to load game data: %v", err)
 	}
+	if len(packReport.Packs) > 1 {
+		var buf strings.Builder
+		packReport.Write(&buf)
+		log.Print(buf.String())
+	}
 	if gameData == nil {
 		log.Fatal("Game data is nil after loading.")
 	}
+	if err := ApplyLoadoutFile(gameData, *loadoutFlag); err == nil {
+		log.Printf("%s の編成を使います。", *loadoutFlag)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("編成ファイルの読み込みに失敗しました: %v", err)
+	}
+	rulesSource, rulesName := dataSource{}, "ai_rules.csv"
+	if *aiRulesFlag != "" {
+		rulesSource, rulesName = fileSource(*aiRulesFlag)
+	} else {
+		src, closeData, err := openPackSource(PackSources(*dataFlag, "")[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer closeData()
+		rulesSource = src
+	}
+	rulesPath := rulesSource.path(rulesName)
+	if *validateAIFlag {
+		problems := ValidateAIRules(rulesSource, rulesName, gameData)
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			fmt.Printf("%s に%d件の問題があります。\n", rulesPath, len(problems))
+			os.Exit(1)
+		}
+		fmt.Printf("%s に問題はありません。\n", rulesPath)
+		return
+	}
+	aiRules, err := LoadAIRules(rulesSource, rulesName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("行動ルールファイルの読み込みに失敗しました (-validateai で詳細を確認できます):\n%v", err)
+	}
 
-	config := LoadConfig()
+	config, err := LoadConfig(*configFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *langFlag != "" {
+		config.UI.Language = *langFlag
+	}
+	if err := SetLanguage(config.UI.Language); err != nil {
+		log.Fatal(err)
+	}
+	gameData.Localize(config.UI.Language)
 
 	game := NewGame(gameData, config, fontFace)
 	if game == nil {
 		log.Fatal("Failed to create new game instance.")
 	}
+	game.BattleMode = battleMode
+	game.SetTeamAI(Team1, team1AI)
+	game.SetTeamAI(Team2, team2AI)
+	game.autoBattleAI = *ai1Flag
+	if len(aiRules) > 0 {
+		for _, team := range []TeamID{Team1, Team2} {
+			game.SetTeamAI(team, NewRuleBasedAI(aiRules, game.teamAI[team]))
+		}
+		log.Printf("%s から%d件の行動ルールを読み込みました。", rulesPath, len(aiRules))
+	}
+	if *devFlag {
+		reloader, err := newHotReloader(PackSources(*dataFlag, *packsFlag), *configFlag, *loadoutFlag, *devApplyFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game.hotReload = reloader
+	}
+	game.SaveFilePath = *saveFileFlag
+	game.ReplayDir = *replayDirFlag
+	game.LogExportDir = *logDirFlag
+	game.LoadoutPath = *loadoutFlag
+	if *seedFlag != 0 {
+		game.SetSeed(*seedFlag)
+	}
+
+	// 外部ボットはセーブデータを読む前に立ち上げ（保存時のボットの有無と照らし合わせるため）、
+	// 以降の終了経路では必ず閉じる（log.Fatal は defer を実行しない）
+	var bots []*ExternalBotAI
+	closeBots := func() {
+		for _, bot := range bots {
+			bot.Close()
+		}
+	}
+	fatal := func(v ...any) {
+		closeBots()
+		log.Fatal(v...)
+	}
+	for team, command := range map[TeamID]string{Team1: *bot1Flag, Team2: *bot2Flag} {
+		if command == "" {
+			continue
+		}
+		bot, err := NewExternalBotAI(command, *botTimeoutFlag, game.teamAI[team])
+		if err != nil {
+			fatal(err)
+		}
+		bots = append(bots, bot)
+		game.SetTeamAI(team, bot)
+	}
+
+	if *loadFlag != "" {
+		save, err := LoadSaveFile(*loadFlag)
+		if err != nil {
+			fatal(err)
+		}
+		if err := game.RestoreSnapshot(save); err != nil {
+			fatal("セーブデータからの再開に失敗しました: ", err)
+		}
+	}
+	if *replayFlag != "" {
+		replay, err := LoadReplayFile(*replayFlag)
+		if err != nil {
+			fatal(err)
+		}
+		if err := game.StartReplay(replay); err != nil {
+			fatal("リプレイの再生に失敗しました: ", err)
+		}
+	} else {
+		game.recordReplay = true
+		if *setupFlag {
+			game.ui.ShowLoadoutEditor(game)
+		}
+	}
+	// チーム1のボットはおまかせのメダロットにしか使われないので、-bot1 は -auto を兼ねる
+	if *autoFlag || *bot1Flag != "" {
+		game.SetTeamAutoBattle(true)
+	}
 
 	ebiten.SetWindowSize(config.UI.Screen.Width, config.UI.Screen.Height)
 	ebiten.SetWindowTitle("Ebiten Medarot Battle")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetTPS(*tpsFlag)
 
-	if err := ebiten.RunGame(game); err != nil {
+	err = ebiten.RunGame(game)
+	// ウィンドウを閉じて決着前に終えたバトルも、そこまでのリプレイを残す
+	game.finishRecording()
+	closeBots()
+	if err != nil {
 		log.Fatal(err)
 	}
 }
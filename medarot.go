@@ -1,59 +1,64 @@
 package main
 
 import (
-	"fmt"
 	"log"
-	"math/rand"
+	"math/rand/v2"
 )
 
 // =============================================================================
 // 初期化 (Initializer)
 // =============================================================================
 
-// InitializeAllMedarots は全てのメダロットデータをロードし、インスタンスを生成する
-func InitializeAllMedarots(gameData *GameData) []*Medarot {
+// InitializeAllMedarots は全てのメダロットデータをロードし、インスタンスを生成する。ログは logger に書く
+func InitializeAllMedarots(gameData *GameData, logger *log.Logger) []*Medarot {
 	var allMedarots []*Medarot
-
 	for _, loadout := range gameData.Medarots {
-		medal := findMedalByID(gameData.Medals, loadout.MedalID)
-		if medal == nil {
-			log.Printf("警告: メダルID '%s' が見つかりません。'%s'にはデフォルトメダルを使用します。", loadout.MedalID, loadout.Name)
-			medal = &Medal{ID: "fallback", Name: "フォールバック", SkillLevel: 1}
-		}
+		allMedarots = append(allMedarots, newMedarotFromLoadout(gameData, loadout, logger))
+	}
 
-		medarot := NewMedarot(
-			loadout.ID,
-			loadout.Name,
-			loadout.Team,
-			medal,
-			loadout.IsLeader,
-			loadout.DrawIndex,
-		)
-
-		partIDMap := map[PartSlotKey]string{
-			PartSlotHead:     loadout.HeadID,
-			PartSlotRightArm: loadout.RightArmID,
-			PartSlotLeftArm:  loadout.LeftArmID,
-			PartSlotLegs:     loadout.LegsID,
-		}
+	logger.Printf("%d体のメダロットを初期化しました。", len(allMedarots))
+	return allMedarots
+}
 
-		for slot, partID := range partIDMap {
-			if p, exists := gameData.AllParts[partID]; exists {
-				// パーツの状態をリセットするために、ここでコピーを行うのが最も安全
-				newPart := *p
-				newPart.IsBroken = false
-				medarot.Parts[slot] = &newPart
-			} else {
-				log.Printf("警告: パーツID '%s' が見つかりません。'%s'の%sスロットは空になります。", partID, medarot.Name, slot)
-				placeholderPart := &Part{ID: "placeholder", PartName: "なし", IsBroken: true}
-				medarot.Parts[slot] = placeholderPart
-			}
-		}
-		allMedarots = append(allMedarots, medarot)
+// newMedarotFromLoadout は編成1体分のメダルとパーツを揃えたメダロットを作る。
+// 見つからないメダル・パーツは代わりのもので埋める（編成画面の能力値の表示にも使う）
+func newMedarotFromLoadout(gameData *GameData, loadout MedarotData, logger *log.Logger) *Medarot {
+	medal := findMedalByID(gameData.Medals, loadout.MedalID)
+	if medal == nil {
+		logger.Printf("警告: メダルID '%s' が見つかりません。'%s'にはデフォルトメダルを使用します。", loadout.MedalID, loadout.Name)
+		medal = &Medal{ID: "fallback", Name: T("medal.fallback"), SkillLevel: 1}
 	}
 
-	log.Printf("%d体のメダロットを初期化しました。", len(allMedarots))
-	return allMedarots
+	medarot := NewMedarot(
+		loadout.ID,
+		loadout.Name,
+		loadout.Team,
+		medal,
+		loadout.IsLeader,
+		loadout.DrawIndex,
+	)
+	medarot.logger = logger
+
+	partIDMap := map[PartSlotKey]string{
+		PartSlotHead:     loadout.HeadID,
+		PartSlotRightArm: loadout.RightArmID,
+		PartSlotLeftArm:  loadout.LeftArmID,
+		PartSlotLegs:     loadout.LegsID,
+	}
+
+	for slot, partID := range partIDMap {
+		if p, exists := gameData.AllParts[partID]; exists {
+			// パーツの状態をリセットするために、ここでコピーを行うのが最も安全
+			newPart := *p
+			newPart.IsBroken = false
+			medarot.Parts[slot] = &newPart
+		} else {
+			logger.Printf("警告: パーツID '%s' が見つかりません。'%s'の%sスロットは空になります。", partID, medarot.Name, slot)
+			placeholderPart := &Part{ID: "placeholder", PartName: T("part.none"), IsBroken: true}
+			medarot.Parts[slot] = placeholderPart
+		}
+	}
+	return medarot
 }
 
 // findMedalByID はIDでメダルを探す（コピーを返す）
@@ -71,7 +76,7 @@ func findMedalByID(allMedals []Medal, id string) *Medal {
 // メダロットのメソッド (Methods)
 // =============================================================================
 
-// NewMedarot は新しいメダロットのインスタンスを生成する（コンストラクタ）
+// NewMedarot は新しいメダロットのインスタンスを生成する（コンストラクタ）。ログは標準のロガーに書く
 func NewMedarot(id, name string, team TeamID, medal *Medal, isLeader bool, drawIndex int) *Medarot {
 	return &Medarot{
 		ID:        id,
@@ -83,6 +88,7 @@ func NewMedarot(id, name string, team TeamID, medal *Medal, isLeader bool, drawI
 		DrawIndex: drawIndex,
 		State:     StateIdle,
 		Gauge:     0.0,
+		logger:    log.Default(),
 	}
 }
 
@@ -91,11 +97,12 @@ func (m *Medarot) ChangeState(newState MedarotState) {
 	if m.State == newState {
 		return
 	}
-	log.Printf("%s のステートが %s から %s に変更されました。", m.Name, m.State, newState)
+	m.logger.Printf("%s のステートが %s から %s に変更されました。", m.Name, m.State, newState)
 	m.State = newState
 
 	switch newState {
 	case StateIdle:
+		m.IdleTicks = 0
 		m.Gauge = 0
 		m.ProgressCounter = 0
 		m.TotalDuration = 0
@@ -112,37 +119,48 @@ func (m *Medarot) ChangeState(newState MedarotState) {
 func (m *Medarot) SelectAndStartCharge(partKey PartSlotKey, target *Medarot, balanceConfig *BalanceConfig) bool {
 	part := m.GetPart(partKey)
 	if part == nil || part.IsBroken {
-		log.Printf("%s: 選択されたパーツ %s は存在しないか破壊されています。", m.Name, partKey)
+		m.logger.Printf("%s: 選択されたパーツ %s は存在しないか破壊されています。", m.Name, partKey)
 		return false
 	}
 	if target == nil || target.State == StateBroken {
-		log.Printf("%s: ターゲットが存在しないか破壊されています。", m.Name)
+		m.logger.Printf("%s: ターゲットが存在しないか破壊されています。", m.Name)
 		return false
 	}
 
 	m.SelectedPartKey = partKey
 	m.TargetedMedarot = target
-	log.Printf("%sは%sで%sを狙う！", m.Name, part.PartName, target.Name)
+	m.logger.Printf("%sは%sで%sを狙う！", m.Name, part.PartName, target.Name)
 
+	m.TotalDuration = m.chargeTicks(part, balanceConfig)
+	m.ChangeState(StateCharging)
+	return true
+}
+
+// chargeTicks はパーツのチャージにかかるティック数を返す。推進力が高いほど短くなる
+func (m *Medarot) chargeTicks(part *Part, balanceConfig *BalanceConfig) float64 {
 	baseSeconds := float64(part.Charge)
 	if baseSeconds <= 0 {
 		baseSeconds = 0.1
 	}
 	propulsionFactor := 1.0 + (float64(m.GetOverallPropulsion()) * balanceConfig.Time.PropulsionEffectRate)
-	totalTicks := (baseSeconds * 60.0) / (balanceConfig.Time.GameSpeedMultiplier * propulsionFactor)
-
-	m.TotalDuration = totalTicks
-	if m.TotalDuration < 1 {
-		m.TotalDuration = 1
+	totalTicks := (baseSeconds * SimulationTicksPerSecond) / (balanceConfig.Time.GameSpeedMultiplier * propulsionFactor)
+	if totalTicks < 1 {
+		totalTicks = 1
 	}
-
-	m.ChangeState(StateCharging)
-	return true
+	return totalTicks
 }
 
 // StartCooldown はクールダウンを開始する
 func (m *Medarot) StartCooldown(balanceConfig *BalanceConfig) {
-	part := m.GetPart(m.SelectedPartKey)
+	m.TotalDuration = cooldownTicks(m.GetPart(m.SelectedPartKey), balanceConfig)
+	m.ProgressCounter = 0
+	m.Gauge = 0
+
+	m.ChangeState(StateCooldown)
+}
+
+// cooldownTicks はパーツ使用後のクールダウンにかかるティック数を返す
+func cooldownTicks(part *Part, balanceConfig *BalanceConfig) float64 {
 	baseSeconds := 1.0
 	if part != nil {
 		baseSeconds = float64(part.Cooldown)
@@ -150,45 +168,60 @@ func (m *Medarot) StartCooldown(balanceConfig *BalanceConfig) {
 	if baseSeconds <= 0 {
 		baseSeconds = 0.1
 	}
-
-	totalTicks := (baseSeconds * 60.0) / balanceConfig.Time.GameSpeedMultiplier
-
-	m.TotalDuration = totalTicks
-	if m.TotalDuration < 1 {
-		m.TotalDuration = 1
+	totalTicks := (baseSeconds * SimulationTicksPerSecond) / balanceConfig.Time.GameSpeedMultiplier
+	if totalTicks < 1 {
+		totalTicks = 1
 	}
-
-	m.ProgressCounter = 0
-	m.Gauge = 0
-
-	m.ChangeState(StateCooldown)
+	return totalTicks
 }
 
-// ExecuteAction は選択された行動を実行する
-func (m *Medarot) ExecuteAction(balanceConfig *BalanceConfig) {
+// ExecuteAction は選択された行動を実行する。乱数はバトル固有の rng から引くので、同じ状態と rng からは同じ結果になる
+func (m *Medarot) ExecuteAction(balanceConfig *BalanceConfig, rng *rand.Rand) {
+	m.LastActionResult = ActionResult{ActorID: m.ID}
 	if m.SelectedPartKey == "" || m.TargetedMedarot == nil {
-		m.LastActionLog = fmt.Sprintf("%sは行動に失敗した。", m.Name)
+		m.LastActionLog = T("battle.action_failed", "name", m.Name)
 		return
 	}
 	part := m.GetPart(m.SelectedPartKey)
+	if part == nil {
+		m.LastActionLog = T("battle.action_failed", "name", m.Name)
+		return
+	}
 	target := m.TargetedMedarot
+	m.LastActionResult.PartID = part.ID
+	m.LastActionResult.PartName = part.PartName
+	m.LastActionResult.Trait = part.Trait
+	m.LastActionResult.TargetID = target.ID
+	// チャージ中に選んだパーツを壊された場合は攻撃できない
+	if part.IsBroken {
+		m.LastActionLog = T("battle.part_unusable", "name", m.Name, "part", part.PartName)
+		return
+	}
 	if target.State == StateBroken {
-		m.LastActionLog = fmt.Sprintf("%sは%sを狙ったが、既に行動不能だった！", m.Name, target.Name)
+		m.LastActionLog = T("battle.target_down", "name", m.Name, "target", target.Name)
 		return
 	}
-	log.Printf("%s が %s を実行！", m.Name, part.PartName)
-	isHit := m.calculateHit(part, target, balanceConfig)
+	m.logger.Printf("%s が %s を実行！", m.Name, part.PartName)
+	m.LastActionResult.Executed = true
+	isHit := m.calculateHit(part, target, balanceConfig, rng)
+	m.LastActionResult.Hit = isHit
 	if isHit {
-		damage, isCritical := m.calculateDamage(part, balanceConfig)
-		targetPart := target.selectRandomPartToDamage()
+		damage, isCritical := m.calculateDamage(part, balanceConfig, rng)
+		targetPart := target.selectRandomPartToDamage(rng)
 		if targetPart != nil {
 			target.applyDamage(targetPart, damage)
+			m.LastActionResult.Damage = damage
+			m.LastActionResult.Critical = isCritical
+			m.LastActionResult.TargetPartID = targetPart.ID
+			m.LastActionResult.TargetPartName = targetPart.PartName
+			m.LastActionResult.TargetPartType = targetPart.Type
+			m.LastActionResult.PartBroken = targetPart.IsBroken
 			m.LastActionLog = m.generateActionLog(target, targetPart, damage, isCritical)
 		} else {
-			m.LastActionLog = fmt.Sprintf("%sの攻撃は%sに当たらなかった。", m.Name, target.Name)
+			m.LastActionLog = T("battle.evaded", "name", m.Name, "target", target.Name)
 		}
 	} else {
-		m.LastActionLog = fmt.Sprintf("%sの%s攻撃は%sに外れた！", m.Name, part.PartName, target.Name)
+		m.LastActionLog = T("battle.missed", "name", m.Name, "part", part.PartName, "target", target.Name)
 	}
 	
 	// 将来の拡張で、行動の結果自身がダメージを受ける効果（カウンター、反動など）によって
@@ -257,56 +290,74 @@ func (m *Medarot) applyDamage(part *Part, damage int) {
 }
 
 // calculateHit は命中判定を行う
-func (m *Medarot) calculateHit(part *Part, target *Medarot, balanceConfig *BalanceConfig) bool {
+func (m *Medarot) calculateHit(part *Part, target *Medarot, balanceConfig *BalanceConfig, rng *rand.Rand) bool {
+	chance := hitChance(part, target, balanceConfig)
+	roll := rng.IntN(100)
+	m.logger.Printf("命中判定: %s -> %s | 命中率: %d, ロール: %d", m.Name, target.Name, chance, roll)
+	return roll < chance
+}
+
+// hitChance は命中率（%）を返す。AIの行動評価でも同じ式を使う
+func hitChance(part *Part, target *Medarot, balanceConfig *BalanceConfig) int {
 	baseChance := balanceConfig.Hit.BaseChance
 	accuracyBonus := part.Accuracy / 2
 	evasionPenalty := target.GetOverallMobility() / 2
 	chance := baseChance + accuracyBonus - evasionPenalty
+	traitBonus := 0
 	switch part.Trait {
 	case TraitAim:
-		chance += balanceConfig.Hit.TraitAimBonus
+		traitBonus = balanceConfig.Hit.TraitAimBonus
 	case TraitStrike:
-		chance += balanceConfig.Hit.TraitStrikeBonus
+		traitBonus = balanceConfig.Hit.TraitStrikeBonus
 	case TraitBerserk:
-		chance += balanceConfig.Hit.TraitBerserkDebuff
+		traitBonus = balanceConfig.Hit.TraitBerserkDebuff
+	}
+	if part.TraitParams.HitBonus != nil {
+		traitBonus = *part.TraitParams.HitBonus
 	}
+	chance += traitBonus
 	if chance < 10 {
 		chance = 10
 	} else if chance > 95 {
 		chance = 95
 	}
-	roll := rand.Intn(100)
-	log.Printf("命中判定: %s -> %s | 命中率: %d, ロール: %d", m.Name, target.Name, chance, roll)
-	return roll < chance
+	return chance
 }
 
 // calculateDamage はダメージ計算を行う
-func (m *Medarot) calculateDamage(part *Part, balanceConfig *BalanceConfig) (int, bool) {
+func (m *Medarot) calculateDamage(part *Part, balanceConfig *BalanceConfig, rng *rand.Rand) (int, bool) {
+	isCritical := rng.IntN(100) < m.criticalChance()
+	return m.potentialDamage(part, balanceConfig, isCritical), isCritical
+}
+
+// criticalChance はクリティカル率（%）を返す
+func (m *Medarot) criticalChance() int {
+	return m.Medal.SkillLevel * 2
+}
+
+// potentialDamage は命中した場合のダメージを、クリティカルかどうかを指定して返す
+func (m *Medarot) potentialDamage(part *Part, balanceConfig *BalanceConfig, isCritical bool) int {
 	baseDamage := part.Power
-	isCritical := false
-	criticalChance := m.Medal.SkillLevel * 2
-	if rand.Intn(100) < criticalChance {
+	if isCritical {
 		baseDamage = int(float64(baseDamage) * balanceConfig.Damage.CriticalMultiplier)
-		isCritical = true
 	}
-	baseDamage += m.Medal.SkillLevel * balanceConfig.Damage.MedalSkillFactor
-	return baseDamage, isCritical
+	return baseDamage + m.Medal.SkillLevel*balanceConfig.Damage.MedalSkillFactor
 }
 
 // generateActionLog は行動ログの文字列を生成する
 func (m *Medarot) generateActionLog(target *Medarot, part *Part, damage int, isCritical bool) string {
-	logMsg := fmt.Sprintf("%sの%sに%dダメージ！", target.Name, part.PartName, damage)
+	logMsg := T("battle.damage", "target", target.Name, "part", part.PartName, "damage", damage)
 	if isCritical {
-		logMsg = fmt.Sprintf("%sの%sにクリティカル！ %dダメージ！", target.Name, part.PartName, damage)
+		logMsg = T("battle.critical", "target", target.Name, "part", part.PartName, "damage", damage)
 	}
 	if part.IsBroken {
-		logMsg += " パーツを破壊した！"
+		logMsg += T("battle.part_broken")
 	}
 	return logMsg
 }
 
 // selectRandomPartToDamage はダメージを受けるパーツをランダムに選択する
-func (m *Medarot) selectRandomPartToDamage() *Part {
+func (m *Medarot) selectRandomPartToDamage(rng *rand.Rand) *Part {
 	vulnerable := []*Part{}
 	slots := []PartSlotKey{PartSlotHead, PartSlotRightArm, PartSlotLeftArm, PartSlotLegs}
 	for _, s := range slots {
@@ -317,5 +368,5 @@ func (m *Medarot) selectRandomPartToDamage() *Part {
 	if len(vulnerable) == 0 {
 		return nil
 	}
-	return vulnerable[rand.Intn(len(vulnerable))]
+	return vulnerable[rng.IntN(len(vulnerable))]
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// runMedasim は "medasim" サブコマンド。ウィンドウを開かずに指定回数のバトルを行い、統計を出力する。
+//
+//	medarot-ebiten medasim -n 1000 -team1 P-01,P-02,P-03 -team2 E-01,E-02,E-03 -format csv -out result.csv
+func runMedasim(args []string) error {
+	fs := flag.NewFlagSet("medasim", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "既定値に重ねる設定ファイル (JSON)")
+	dataDir := fs.String("data", "", "標準データの代わりに読むデータのディレクトリか zip (省略時は埋め込みのデータ)")
+	packs := fs.String("packs", "", "標準データの上に重ねるデータパックのディレクトリか zip (カンマ区切り、後ろほど優先)")
+	battles := fs.Int("n", 100, "バトルの回数")
+	seed := fs.Uint64("seed", 1, "最初のバトルのシード。i 戦目は seed+i を使う")
+	team1 := fs.String("team1", "", "チーム1のメダロットID（カンマ区切り、先頭がリーダー）。省略時は medarots.csv のチーム0")
+	team2 := fs.String("team2", "", "チーム2のメダロットID（カンマ区切り、先頭がリーダー）。省略時は medarots.csv のチーム1")
+	ai1 := fs.String("ai1", string(AINormal), "チーム1のAI (easy, normal, hard, expert, montecarlo, team)")
+	ai2 := fs.String("ai2", string(AINormal), "チーム2のAI (easy, normal, hard, expert, montecarlo, team)")
+	aiRules := fs.String("airules", "", "メダロットごとの行動ルールファイル（省略時は使わない）")
+	mode := fs.String("mode", string(ModeWait), "バトルモード (active, wait, turn)")
+	maxTicks := fs.Int("maxticks", defaultSimulationMaxTicks, "1バトルの最大ティック数。超えたら引き分け")
+	workers := fs.Int("workers", 0, "並列に実行するバトル数 (0 なら CPU 数)")
+	format := fs.String("format", "text", "出力形式 (text, csv, json)")
+	out := fs.String("out", "", "出力ファイル（省略時は標準出力）")
+	fs.Parse(args)
+
+	battleMode, err := ParseBattleMode(*mode)
+	if err != nil {
+		return err
+	}
+	gameData, _, err := LoadDataPacks(PackSources(*dataDir, *packs))
+	if err != nil {
+		return err
+	}
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	opts := SimulationOptions{
+		GameData: gameData,
+		Balance:  config.Balance,
+		AI1:      *ai1,
+		AI2:      *ai2,
+		Mode:     battleMode,
+		Seed:     *seed,
+		Battles:  *battles,
+		MaxTicks: *maxTicks,
+		Workers:  *workers,
+	}
+	if opts.Team1, err = selectLoadouts(gameData, *team1, Team1); err != nil {
+		return err
+	}
+	if opts.Team2, err = selectLoadouts(gameData, *team2, Team2); err != nil {
+		return err
+	}
+	if *aiRules != "" {
+		if opts.Rules, err = LoadAIRules(fileSource(*aiRules)); err != nil {
+			return fmt.Errorf("行動ルールファイルの読み込みに失敗: %w", err)
+		}
+	}
+
+	started := time.Now()
+	report, err := RunSimulations(opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d回のバトルを %v で実行しました。\n", report.Battles, time.Since(started).Round(time.Millisecond))
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("出力ファイルの作成に失敗: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	switch *format {
+	case "text":
+		writeReportText(w, report)
+		return nil
+	case "csv":
+		return writeReportCSV(w, report)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return fmt.Errorf("未対応の出力形式です: %s (text, csv, json のいずれか)", *format)
+}
+
+// writeReportText は集計結果を人が読みやすい表にして書き出す
+func writeReportText(w io.Writer, r *SimulationReport) {
+	fmt.Fprintf(w, "バトル数: %d\n", r.Battles)
+	fmt.Fprintf(w, "チーム1勝利: %d (%.1f%%)  チーム2勝利: %d (%.1f%%)  引き分け: %d\n",
+		r.Team1Wins, r.Team1WinRate*100, r.Team2Wins, r.Team2WinRate*100, r.Draws)
+	fmt.Fprintf(w, "平均バトル時間: %.1f ティック (%.1f 秒)\n", r.AvgTicks, r.AvgSeconds)
+
+	fmt.Fprintf(w, "\nパーツ別ダメージ\n")
+	fmt.Fprintf(w, "%-8s %6s %6s %8s %8s  %s\n", "ID", "使用", "命中", "ダメージ", "平均", "パーツ")
+	for _, p := range r.Parts {
+		fmt.Fprintf(w, "%-8s %6d %6d %8d %8.1f  %s\n", p.PartID, p.Uses, p.Hits, p.Damage, p.AvgDamage, p.PartName)
+	}
+
+	fmt.Fprintf(w, "\n特性別命中率\n")
+	fmt.Fprintf(w, "%-8s %6s %6s %6s %8s %8s\n", "特性", "使用", "判定", "命中", "命中率", "使用率")
+	for _, t := range r.Traits {
+		fmt.Fprintf(w, "%-8s %6d %6d %6d %7.1f%% %7.1f%%\n", t.Trait, t.Uses, t.Attempts, t.Hits, t.HitRate*100, t.UseRate*100)
+	}
+
+	fmt.Fprintf(w, "\nよく破壊されたパーツ\n")
+	for _, b := range r.BrokenParts {
+		fmt.Fprintf(w, "%-8s %6d  %s\n", b.PartID, b.Broken, b.PartName)
+	}
+}
+
+// writeReportCSV は集計結果を "section,name,metric,value" の縦持ちの CSV で書き出す（表計算ソフトのピボット向け）
+func writeReportCSV(w io.Writer, r *SimulationReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"section", "name", "metric", "value"})
+	row := func(section, name, metric string, value float64) {
+		cw.Write([]string{section, name, metric, strconv.FormatFloat(value, 'f', -1, 64)})
+	}
+	row("summary", "", "battles", float64(r.Battles))
+	row("summary", "", "team1_wins", float64(r.Team1Wins))
+	row("summary", "", "team2_wins", float64(r.Team2Wins))
+	row("summary", "", "draws", float64(r.Draws))
+	row("summary", "", "team1_win_rate", r.Team1WinRate)
+	row("summary", "", "team2_win_rate", r.Team2WinRate)
+	row("summary", "", "avg_ticks", r.AvgTicks)
+	row("summary", "", "avg_seconds", r.AvgSeconds)
+	for _, p := range r.Parts {
+		name := p.PartID + " " + p.PartName
+		row("part", name, "uses", float64(p.Uses))
+		row("part", name, "hits", float64(p.Hits))
+		row("part", name, "damage", float64(p.Damage))
+		row("part", name, "avg_damage", p.AvgDamage)
+	}
+	for _, t := range r.Traits {
+		name := string(t.Trait)
+		row("trait", name, "uses", float64(t.Uses))
+		row("trait", name, "attempts", float64(t.Attempts))
+		row("trait", name, "hits", float64(t.Hits))
+		row("trait", name, "hit_rate", t.HitRate)
+		row("trait", name, "use_rate", t.UseRate)
+	}
+	for _, b := range r.BrokenParts {
+		row("broken", b.PartID+" "+b.PartName, "broken", float64(b.Broken))
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReplayFormatVersion はリプレイファイルの形式バージョン
+const ReplayFormatVersion = 1
+
+// replayFastForwardSpeed は早送り時のシミュレーション速度倍率
+const replayFastForwardSpeed = 4
+
+// replaySeekSeconds はシークボタン1回で移動する時間（秒）
+const replaySeekSeconds = 10
+
+// Replay は1バトル分のリプレイ。開始時点の状態（シード・ロードアウト・バランス設定を含む）と、
+// 両チームの行動決定を順番に記録する。命中などの乱数はバトルの rng から再現されるので、
+// 決定さえ同じティックに適用すれば同じ展開になる。
+type Replay struct {
+	Version    int
+	RecordedAt string
+	Initial    SaveData
+	Decisions  []ReplayDecision
+	FinalTick  int
+	Finished   bool
+	Winner     TeamID
+}
+
+// ReplayDecision は1回の行動決定（どのメダロットが、いつ、どのパーツで誰を狙ったか）
+type ReplayDecision struct {
+	Tick      int
+	MedarotID string
+	Slot      PartSlotKey
+	TargetID  string
+}
+
+// replayPlayer はリプレイ再生中の状態を保持する
+type replayPlayer struct {
+	data        *Replay
+	cursor      int
+	paused      bool
+	fastForward bool
+}
+
+// commitAction はメダロットの行動を確定してチャージを開始する。
+// プレイヤー・AIを問わず行動決定はここを通り、リプレイ記録中なら決定内容を残す。
+func (g *Game) commitAction(m *Medarot, slot PartSlotKey, target *Medarot) bool {
+	if !g.startCharge(m, slot, target) {
+		return false
+	}
+	if g.recorder != nil {
+		g.recorder.Decisions = append(g.recorder.Decisions, ReplayDecision{
+			Tick:      g.TickCount,
+			MedarotID: m.ID,
+			Slot:      slot,
+			TargetID:  target.ID,
+		})
+	}
+	return true
+}
+
+// startCharge はチャージを開始し、その内容をバトルログに残す
+func (g *Game) startCharge(m *Medarot, slot PartSlotKey, target *Medarot) bool {
+	if !m.SelectAndStartCharge(slot, target, &g.Config.Balance) {
+		return false
+	}
+	g.addBattleLog(LogKindSelect, m.Team, T("battle.select", "name", m.Name, "part", m.GetPart(slot).PartName, "target", target.Name), nil)
+	return true
+}
+
+// startRecording は現在の状態を起点にリプレイの記録を始める
+func (g *Game) startRecording() {
+	initial, err := g.Snapshot()
+	if err != nil {
+		log.Printf("リプレイの記録を開始できません: %v", err)
+		g.recordReplay = false
+		return
+	}
+	g.recorder = &Replay{
+		Version:    ReplayFormatVersion,
+		RecordedAt: time.Now().Format(time.RFC3339),
+		Initial:    *initial,
+	}
+}
+
+// SaveReplayRecording は記録中のリプレイをリプレイディレクトリに書き出し、そのパスを返す
+func (g *Game) SaveReplayRecording() (string, error) {
+	if g.recorder == nil {
+		return "", fmt.Errorf("記録中のリプレイがありません")
+	}
+	g.recorder.FinalTick = g.TickCount
+	g.recorder.Finished = g.State == StateGameOver
+	g.recorder.Winner = g.winner
+	if err := os.MkdirAll(g.ReplayDir, 0755); err != nil {
+		return "", fmt.Errorf("リプレイディレクトリの作成に失敗: %w", err)
+	}
+	// 途中までのリプレイと決着時のリプレイが同じ秒に書き出されても上書きしないよう、番号を付けて分ける
+	base := filepath.Join(g.ReplayDir, "replay_"+time.Now().Format("20060102_150405"))
+	path := base + ".json"
+	for n := 2; fileExists(path); n++ {
+		path = fmt.Sprintf("%s_%d.json", base, n)
+	}
+	data, err := json.MarshalIndent(g.recorder, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("リプレイの変換に失敗: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("リプレイの書き込みに失敗: %w", err)
+	}
+	log.Printf("リプレイを %s に保存しました (%d件の行動, tick %d)。", path, len(g.recorder.Decisions), g.TickCount)
+	return path, nil
+}
+
+// fileExists は path にファイルが既にあるかを返す
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// finishRecording は記録中のリプレイを書き出して記録を終える。決着前にバトルを捨てるとき
+// （データの再読み込み・やり直し・ロード・終了）にも呼び、そこまでの記録を Finished=false で残す。
+// まだ誰も行動を決めていなければ書き出さない。
+func (g *Game) finishRecording() {
+	if g.recorder == nil {
+		return
+	}
+	if len(g.recorder.Decisions) > 0 || g.State == StateGameOver {
+		if _, err := g.SaveReplayRecording(); err != nil {
+			log.Printf("リプレイの保存に失敗しました: %v", err)
+		}
+	}
+	g.recorder = nil
+}
+
+// LoadReplayFile はリプレイファイルを読み込む
+func LoadReplayFile(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("リプレイの読み込みに失敗: %w", err)
+	}
+	var replay Replay
+	if err := json.Unmarshal(data, &replay); err != nil {
+		return nil, fmt.Errorf("リプレイの解析に失敗: %w", err)
+	}
+	if replay.Version != ReplayFormatVersion {
+		return nil, fmt.Errorf("未対応のリプレイのバージョンです: %d (対応: %d)", replay.Version, ReplayFormatVersion)
+	}
+	return &replay, nil
+}
+
+// StartReplay はゲームをリプレイ再生モードにし、記録の開始時点から再シミュレーションを始める
+func (g *Game) StartReplay(replay *Replay) error {
+	g.replay = &replayPlayer{data: replay}
+	g.recordReplay = false
+	g.recorder = nil
+	g.autoAcknowledge = true
+	return g.seekReplay(replay.Initial.Tick)
+}
+
+// seekReplay は開始状態から指定ティックまでを一気に再シミュレーションする
+func (g *Game) seekReplay(tick int) error {
+	data := g.replay.data
+	if tick < data.Initial.Tick {
+		tick = data.Initial.Tick
+	}
+	if tick > data.FinalTick {
+		tick = data.FinalTick
+	}
+	if err := g.RestoreSnapshot(&data.Initial); err != nil {
+		return err
+	}
+	g.replay.cursor = 0
+	for g.TickCount < tick && g.State == StatePlaying {
+		g.Step()
+	}
+	return nil
+}
+
+// applyReplayDecisions は現在のティックまでに記録された行動決定を順に適用する
+func (g *Game) applyReplayDecisions() {
+	decisions := g.replay.data.Decisions
+	for g.replay.cursor < len(decisions) {
+		d := decisions[g.replay.cursor]
+		if d.Tick > g.TickCount {
+			return
+		}
+		g.replay.cursor++
+		m := g.findMedarotByID(d.MedarotID)
+		target := g.findMedarotByID(d.TargetID)
+		if m == nil || target == nil || m.State != StateIdle {
+			g.logger.Printf("警告: リプレイの行動 (tick %d, %s) を適用できません。記録と展開がずれています。", d.Tick, d.MedarotID)
+			continue
+		}
+		g.startCharge(m, d.Slot, target)
+	}
+}
+
+// atEnd は記録された最後のティックまで再生し終えたかを返す
+func (p *replayPlayer) atEnd(tick int) bool {
+	return tick >= p.data.FinalTick
+}
+
+// findMedarotByID はIDでメダロットを探す
+func (g *Game) findMedarotByID(id string) *Medarot {
+	for _, m := range g.Medarots {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
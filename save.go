@@ -0,0 +1,421 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
+)
+
+// SaveFormatVersion はセーブデータの形式バージョン。互換性のない変更をしたら上げ、migrateSaveData に読み替えを足す
+//   - 2: TeamPlans（チーム単位のAIの作戦）を追加
+//   - 3: TeamAI（チームごとのAI・行動ルール・外部ボット）と AutoBattleAI を追加
+const SaveFormatVersion = 3
+
+// SaveData はバトル途中の状態をまるごと保存するための構造体。
+// ポインタで結ばれた Medarot/Part は ID で参照し直して保存する。
+type SaveData struct {
+	Version      int
+	Tick         int
+	Mode         BattleMode
+	State        GameState
+	PlayerTeam   TeamID
+	Winner       TeamID
+	Seed         uint64
+	RNGState     []byte
+	AIRNGState   []byte
+	Balance      BalanceConfig
+	Medarots     []SavedMedarot
+	ActionQueue  []string
+	AutoBattle   []string
+	AutoBattleAI string
+	TeamAI       []SavedTeamAI
+	TeamPlans    []SavedTeamPlan
+}
+
+// SavedTeamAI はチームのAIの構成。AIや行動ルールが違うと同じ状態から再開しても展開が変わるので、
+// 再開時には保存したとおりに作り直す。外部ボットはプロセスを作り直せないので、使っているかどうかが一致しなければ再開しない。
+type SavedTeamAI struct {
+	Team  TeamID
+	AI    string        // 行動を決める一番下のAIの名前 (NewTeamAI に渡す名前)
+	Rules []SavedAIRule // 行動ルール。使っていなければ空
+	Bot   string        // 外部ボットのコマンド。使っていなければ空
+}
+
+// SavedAIRule は行動ルール1行の保存形式
+type SavedAIRule struct {
+	Line   int
+	Record []string
+}
+
+// SavedTeamPlan は TeamPlannerAI の作戦の保存形式。作戦を立てた時点の状況も保存しないと、
+// 再開後に立て直すタイミングがずれて中断しなかった場合と展開が変わる。
+type SavedTeamPlan struct {
+	Team        TeamID
+	Plan        map[string]string
+	BrokenParts int
+	Threats     []string
+}
+
+// SavedMedarot は1体のメダロットの保存形式。パーツはステータスごと保存するので、
+// CSV が変更されても再開後のバトル結果は変わらない。
+type SavedMedarot struct {
+	ID              string
+	Name            string
+	Team            TeamID
+	IsLeader        bool
+	DrawIndex       int
+	Medal           Medal
+	Parts           map[PartSlotKey]Part
+	State           MedarotState
+	Gauge           float64
+	ProgressCounter float64
+	TotalDuration   float64
+	IdleTicks       int
+	SelectedPartKey PartSlotKey
+	TargetID        string
+	LastActionLog   string
+}
+
+// Snapshot は現在のバトル状態を SaveData に変換する
+func (g *Game) Snapshot() (*SaveData, error) {
+	rngState, err := g.rngSource.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("乱数状態の保存に失敗: %w", err)
+	}
+	aiRNGState, err := g.aiRNGSource.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("AI用乱数状態の保存に失敗: %w", err)
+	}
+	state := StatePlaying
+	if g.State == StateGameOver {
+		state = StateGameOver
+	}
+	save := &SaveData{
+		Version:    SaveFormatVersion,
+		Tick:       g.TickCount,
+		Mode:       g.BattleMode,
+		State:      state,
+		PlayerTeam: g.PlayerTeam,
+		Winner:     g.winner,
+		Seed:       g.Seed,
+		RNGState:   rngState,
+		AIRNGState: aiRNGState,
+		Balance:    g.Config.Balance,
+	}
+	for _, m := range g.Medarots {
+		saved := SavedMedarot{
+			ID:              m.ID,
+			Name:            m.Name,
+			Team:            m.Team,
+			IsLeader:        m.IsLeader,
+			DrawIndex:       m.DrawIndex,
+			Medal:           *m.Medal,
+			Parts:           make(map[PartSlotKey]Part),
+			State:           m.State,
+			Gauge:           m.Gauge,
+			ProgressCounter: m.ProgressCounter,
+			TotalDuration:   m.TotalDuration,
+			IdleTicks:       m.IdleTicks,
+			SelectedPartKey: m.SelectedPartKey,
+			LastActionLog:   m.LastActionLog,
+		}
+		for slot, part := range m.Parts {
+			saved.Parts[slot] = *part
+		}
+		if m.TargetedMedarot != nil {
+			saved.TargetID = m.TargetedMedarot.ID
+		}
+		save.Medarots = append(save.Medarots, saved)
+	}
+	for _, m := range g.actionQueue {
+		save.ActionQueue = append(save.ActionQueue, m.ID)
+	}
+	for _, m := range g.Medarots {
+		if g.autoBattle[m.ID] {
+			save.AutoBattle = append(save.AutoBattle, m.ID)
+		}
+	}
+	save.AutoBattleAI = g.autoBattleAI
+	for _, team := range []TeamID{Team1, Team2} {
+		saved, err := saveTeamAI(team, g.teamAI[team])
+		if err != nil {
+			return nil, err
+		}
+		save.TeamAI = append(save.TeamAI, saved)
+	}
+	for _, team := range []TeamID{Team1, Team2} {
+		if planner := teamPlannerOf(g.teamAI[team]); planner != nil && planner.plan != nil {
+			save.TeamPlans = append(save.TeamPlans, SavedTeamPlan{
+				Team:        team,
+				Plan:        maps.Clone(planner.plan),
+				BrokenParts: planner.planBrokenParts,
+				Threats:     slices.Clone(planner.planThreats),
+			})
+		}
+	}
+	return save, nil
+}
+
+// teamPlannerOf はチームのAIが（ルールや外部ボットの代わりとしても）使っている TeamPlannerAI を返す。無ければ nil
+func teamPlannerOf(strategy AIStrategy) *TeamPlannerAI {
+	planner, _ := baseAI(strategy).(*TeamPlannerAI)
+	return planner
+}
+
+// saveTeamAI はチームのAI（外部ボット → 行動ルール → 一番下のAI の順に包まれている）を保存形式にする
+func saveTeamAI(team TeamID, strategy AIStrategy) (SavedTeamAI, error) {
+	saved := SavedTeamAI{Team: team}
+	if strategy == nil {
+		strategy = NewScoringAI(aiProfiles[AINormal]) // strategyFor が割り当てるものと同じ
+	}
+	if bot, ok := strategy.(*ExternalBotAI); ok {
+		saved.Bot = bot.Command
+		strategy = bot.Fallback
+	}
+	if rules, ok := strategy.(*RuleBasedAI); ok {
+		for _, list := range rules.Rules {
+			for _, rule := range list {
+				saved.Rules = append(saved.Rules, SavedAIRule{Line: rule.Line, Record: slices.Clone(rule.Record)})
+			}
+		}
+		slices.SortFunc(saved.Rules, func(a, b SavedAIRule) int { return a.Line - b.Line })
+		strategy = rules.Fallback
+	}
+	name, err := teamAIName(strategy)
+	if err != nil {
+		return saved, fmt.Errorf("%sのAIを保存できません: %w", teamLabel(team), err)
+	}
+	saved.AI = name
+	return saved, nil
+}
+
+// teamAIName は NewTeamAI で同じAIを作り直せる名前を返す
+func teamAIName(strategy AIStrategy) (string, error) {
+	switch ai := strategy.(type) {
+	case *MonteCarloAI:
+		return AIMonteCarlo, nil
+	case *TeamPlannerAI:
+		return AITeamPlanner, nil
+	case *ScoringAI:
+		if standard, ok := aiProfiles[ai.Profile.Difficulty]; ok && standard == ai.Profile {
+			return string(ai.Profile.Difficulty), nil
+		}
+		return "", fmt.Errorf("難易度の標準と違うプロファイルの AI です")
+	}
+	return "", fmt.Errorf("%T は名前で作り直せません", strategy)
+}
+
+// restoreTeamAI は保存されたチームのAIを作り直す。今のチームのAIが外部ボットなら、その代わりに使うAIとして返す
+// （ボットの Fallback の差し替えは呼び出し側で行う）。外部ボットの有無が保存時と違えばエラーを返す
+func (g *Game) restoreTeamAI(saved SavedTeamAI) (AIStrategy, error) {
+	strategy, err := NewTeamAI(saved.AI)
+	if err != nil {
+		return nil, fmt.Errorf("%sのAI: %w", teamLabel(saved.Team), err)
+	}
+	if len(saved.Rules) > 0 {
+		rules := make([]AIRule, 0, len(saved.Rules))
+		for _, s := range saved.Rules {
+			rule, problems := parseAIRule(s.Record, s.Line)
+			if len(problems) > 0 {
+				return nil, fmt.Errorf("%sの行動ルール (%d行目) を作り直せません: %w", teamLabel(saved.Team), s.Line, errors.Join(problems...))
+			}
+			rules = append(rules, rule)
+		}
+		strategy = NewRuleBasedAI(rules, strategy)
+	}
+	// リプレイの再生では記録された行動だけを使うので、ボットの有無は問わない
+	if g.replay == nil {
+		_, hasBot := g.teamAI[saved.Team].(*ExternalBotAI)
+		flag := fmt.Sprintf("-bot%d", saved.Team+1)
+		if saved.Bot != "" && !hasBot {
+			return nil, fmt.Errorf("%sは外部ボット (%s) を使って保存されています。%s で同じボットを指定してください", teamLabel(saved.Team), saved.Bot, flag)
+		}
+		if saved.Bot == "" && hasBot {
+			return nil, fmt.Errorf("%sは外部ボットを使わずに保存されています。%s を外してください", teamLabel(saved.Team), flag)
+		}
+	}
+	return strategy, nil
+}
+
+// migrateSaveData は古い形式のセーブデータを現在の形式として読めるようにする
+func migrateSaveData(save *SaveData) error {
+	switch save.Version {
+	case SaveFormatVersion:
+		return nil
+	case 1:
+		// v1 には作戦が無い。TeamPlannerAI は再開後の最初の行動で作戦を立て直す
+		log.Printf("古い形式 (バージョン1) のセーブデータです。チームAIの作戦は再開時に立て直します。")
+		fallthrough
+	case 2:
+		// v2 まではAIの構成を保存していないので、今のAIのまま続ける
+		log.Printf("古い形式 (バージョン%d) のセーブデータにはAIの設定がありません。今のAIで再開します。", save.Version)
+		save.Version = SaveFormatVersion
+		return nil
+	}
+	return fmt.Errorf("未対応のセーブデータのバージョンです: %d (対応: %d)", save.Version, SaveFormatVersion)
+}
+
+// RestoreSnapshot は SaveData からバトル状態を復元する。UI はメダロットを参照しているので作り直す。
+func (g *Game) RestoreSnapshot(save *SaveData) error {
+	if err := migrateSaveData(save); err != nil {
+		return err
+	}
+	rngSource := &rand.PCG{}
+	if err := rngSource.UnmarshalBinary(save.RNGState); err != nil {
+		return fmt.Errorf("乱数状態の復元に失敗: %w", err)
+	}
+	aiRNGSource := rand.NewPCG(save.Seed, 1)
+	if len(save.AIRNGState) > 0 {
+		if err := aiRNGSource.UnmarshalBinary(save.AIRNGState); err != nil {
+			return fmt.Errorf("AI用乱数状態の復元に失敗: %w", err)
+		}
+	}
+
+	medarots := make([]*Medarot, 0, len(save.Medarots))
+	byID := make(map[string]*Medarot)
+	for _, saved := range save.Medarots {
+		medal := saved.Medal
+		m := NewMedarot(saved.ID, saved.Name, saved.Team, &medal, saved.IsLeader, saved.DrawIndex)
+		for slot, part := range saved.Parts {
+			p := part
+			m.Parts[slot] = &p
+		}
+		m.State = saved.State
+		m.Gauge = saved.Gauge
+		m.ProgressCounter = saved.ProgressCounter
+		m.TotalDuration = saved.TotalDuration
+		m.IdleTicks = saved.IdleTicks
+		m.SelectedPartKey = saved.SelectedPartKey
+		m.LastActionLog = saved.LastActionLog
+		medarots = append(medarots, m)
+		byID[m.ID] = m
+	}
+	for i, saved := range save.Medarots {
+		if saved.TargetID == "" {
+			continue
+		}
+		target, ok := byID[saved.TargetID]
+		if !ok {
+			return fmt.Errorf("%s のターゲット '%s' がセーブデータに存在しません", saved.Name, saved.TargetID)
+		}
+		medarots[i].TargetedMedarot = target
+	}
+	queue := make([]*Medarot, 0, len(save.ActionQueue))
+	queued := make(map[string]bool)
+	for _, id := range save.ActionQueue {
+		m, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("実行キューのメダロット '%s' がセーブデータに存在しません", id)
+		}
+		queue = append(queue, m)
+		queued[id] = true
+	}
+	teamAI := make(map[TeamID]AIStrategy)
+	for _, saved := range save.TeamAI {
+		strategy, err := g.restoreTeamAI(saved)
+		if err != nil {
+			return err
+		}
+		teamAI[saved.Team] = strategy
+	}
+
+	// ロードで捨てるバトルのリプレイを残す
+	g.finishRecording()
+	g.Medarots = medarots
+	g.actionQueue = queue
+	g.TickCount = save.Tick
+	g.BattleMode = save.Mode
+	g.PlayerTeam = save.PlayerTeam
+	g.winner = save.Winner
+	g.Config.Balance = save.Balance
+	g.Seed = save.Seed
+	g.rngSource = rngSource
+	g.rng = rand.New(rngSource)
+	g.aiRNGSource = aiRNGSource
+	g.aiRNG = rand.New(aiRNGSource)
+	g.State = save.State
+	g.message = ""
+	g.postMessageCallback = nil
+	g.playerMedarotToAct = nil
+	g.battleLog = nil
+	g.autoBattle = make(map[string]bool)
+	for _, id := range save.AutoBattle {
+		g.autoBattle[id] = true
+	}
+	for team, strategy := range teamAI {
+		if bot, ok := g.teamAI[team].(*ExternalBotAI); ok {
+			bot.Fallback = strategy
+			strategy = bot
+		}
+		g.SetTeamAI(team, strategy)
+	}
+	if save.AutoBattleAI != "" {
+		g.autoBattleAI = save.AutoBattleAI
+	}
+	for _, team := range []TeamID{Team1, Team2} {
+		if planner := teamPlannerOf(g.teamAI[team]); planner != nil {
+			planner.plan, planner.planBrokenParts, planner.planThreats = nil, -1, nil
+		}
+	}
+	for _, saved := range save.TeamPlans {
+		planner := teamPlannerOf(g.teamAI[saved.Team])
+		if planner == nil {
+			g.logger.Printf("チーム%dのAIがチームの作戦を使わないため、保存された作戦は使いません。", saved.Team+1)
+			continue
+		}
+		planner.plan, planner.planBrokenParts, planner.planThreats = saved.Plan, saved.BrokenParts, saved.Threats
+	}
+	g.stopSimulationClock()
+	g.initializeMedarotLists()
+
+	// 行動結果のメッセージ表示中に保存された場合、実行済みのメダロットが StateReady のまま
+	// キューから外れているので、メッセージを閉じた後の処理をここで行う
+	if g.State != StateGameOver {
+		for _, m := range g.Medarots {
+			if m.State == StateReady && !queued[m.ID] {
+				g.finishAction(m)
+			}
+		}
+	}
+
+	if g.ui != nil {
+		g.ui = NewUI(g)
+	}
+	g.logger.Printf("バトル状態を復元しました (tick %d)。", g.TickCount)
+	return nil
+}
+
+// SaveGameToFile は現在のバトル状態を JSON ファイルに書き出す
+func (g *Game) SaveGameToFile(path string) error {
+	save, err := g.Snapshot()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(save, "", "  ")
+	if err != nil {
+		return fmt.Errorf("セーブデータの変換に失敗: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("セーブデータの書き込みに失敗: %w", err)
+	}
+	g.logger.Printf("バトル状態を %s に保存しました (tick %d)。", path, g.TickCount)
+	return nil
+}
+
+// LoadSaveFile は JSON ファイルからセーブデータを読み込む
+func LoadSaveFile(path string) (*SaveData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("セーブデータの読み込みに失敗: %w", err)
+	}
+	var save SaveData
+	if err := json.Unmarshal(data, &save); err != nil {
+		return nil, fmt.Errorf("セーブデータの解析に失敗: %w", err)
+	}
+	return &save, nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSaveSeed は中断・再開のテストで使うバトルのシード
+const testSaveSeed = 20240601
+
+// newTestBattle は両チームを同じ種類のAIに任せた UI なしのバトルを作る。進行ログは出さない
+func newTestBattle(t *testing.T, data *GameData, ai string) *Game {
+	t.Helper()
+	g := newBattle(data, DefaultConfig(), quietLogger)
+	g.PlayerTeam = TeamNone
+	g.SetSeed(testSaveSeed)
+	for _, team := range []TeamID{Team1, Team2} {
+		strategy, err := NewTeamAI(ai)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.SetTeamAI(team, strategy)
+	}
+	return g
+}
+
+// battleFingerprint は勝敗とメダロット全員の最終状態を比較用の文字列にまとめる
+func battleFingerprint(g *Game) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tick=%d winner=%d\n", g.TickCount, g.winner)
+	for _, m := range g.Medarots {
+		fmt.Fprintf(&b, "%s state=%v gauge=%.3f", m.ID, m.State, m.Gauge)
+		for _, slot := range []PartSlotKey{PartSlotHead, PartSlotRightArm, PartSlotLeftArm, PartSlotLegs} {
+			if part := m.GetPart(slot); part != nil {
+				fmt.Fprintf(&b, " %s=%d", slot, part.Armor)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// TestSaveResumeMatchesUninterruptedBattle はバトルの途中でファイルに保存して再開しても、
+// 中断しなかった場合と同じ結末になることを確かめる
+func TestSaveResumeMatchesUninterruptedBattle(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const maxTicks = 36000
+	for _, ai := range []string{string(AIEasy), string(AIHard), AITeamPlanner} {
+		t.Run(ai, func(t *testing.T) {
+			g := newTestBattle(t, data, ai)
+			g.runSimulation(maxTicks)
+			want, finalTick := battleFingerprint(g), g.TickCount
+			if finalTick == 0 {
+				t.Fatal("バトルが進みませんでした")
+			}
+			// 作戦の立て直しや行動結果のメッセージなど、どの時点で中断しても結末が変わらないよう全ティックで試す
+			for saveTick := 1; saveTick < finalTick; saveTick++ {
+				path := filepath.Join(t.TempDir(), "save.json")
+				var got string
+				restoreErr := func() error {
+					g := newTestBattle(t, data, ai)
+					for g.TickCount < saveTick && g.State != StateGameOver {
+						g.Step()
+					}
+					if err := g.SaveGameToFile(path); err != nil {
+						return err
+					}
+					save, err := LoadSaveFile(path)
+					if err != nil {
+						return err
+					}
+					resumed := newTestBattle(t, data, ai)
+					if err := resumed.RestoreSnapshot(save); err != nil {
+						return err
+					}
+					// 結末に響かない状態（AIの作戦など）も、保存したとおりに戻っているか確かめる
+					again, err := resumed.Snapshot()
+					if err != nil {
+						return err
+					}
+					if !reflect.DeepEqual(again, save) {
+						return fmt.Errorf("復元した状態を保存し直すと内容が変わります")
+					}
+					resumed.runSimulation(maxTicks)
+					got = battleFingerprint(resumed)
+					return nil
+				}()
+				if restoreErr != nil {
+					t.Fatalf("tick %d: %v", saveTick, restoreErr)
+				}
+				if got != want {
+					t.Errorf("tick %d で保存・再開すると結末が変わりました\n再開: %s\n中断なし: %s", saveTick, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestRestoreSnapshotRejectsUnknownVersion は未対応のバージョンのセーブデータを読み込まないことを確かめる
+func TestRestoreSnapshotRejectsUnknownVersion(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestBattle(t, data, string(AINormal))
+	save, err := g.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	save.Version = SaveFormatVersion + 1
+	if err := g.RestoreSnapshot(save); err == nil {
+		t.Error("新しすぎるバージョンのセーブデータを読み込みました")
+	}
+}
+
+// TestRestoreSnapshotMigratesVersion1 は作戦を持たないバージョン1のセーブデータも読み込めることを確かめる
+func TestRestoreSnapshotMigratesVersion1(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestBattle(t, data, AITeamPlanner)
+	save, err := g.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	save.Version = 1
+	save.TeamAI = nil
+	save.TeamPlans = nil
+	if err := g.RestoreSnapshot(save); err != nil {
+		t.Fatalf("バージョン1のセーブデータを読み込めません: %v", err)
+	}
+	g.runSimulation(36000)
+	if g.State != StateGameOver {
+		t.Error("バージョン1から再開したバトルが決着しませんでした")
+	}
+}
+
+// TestRestoreSnapshotRestoresTeamAI は、違うAIで起動したゲームにロードしても、保存時のAI・行動ルール・おまかせのAIで
+// 再開して中断しなかった場合と同じ結末になることを確かめる
+func TestRestoreSnapshotRestoresTeamAI(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, problems := parseAIRule([]string{"E-03", "", "category:FIGHT", "lowest_head_armor"}, 2)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	newOriginal := func() *Game {
+		g := newTestBattle(t, data, string(AIHard))
+		g.SetTeamAI(Team2, NewRuleBasedAI([]AIRule{rule}, g.teamAI[Team2]))
+		g.autoBattleAI = string(AIHard)
+		return g
+	}
+	const saveTick, maxTicks = 300, 36000
+	original := newOriginal()
+	original.runSimulation(maxTicks)
+	want := battleFingerprint(original)
+
+	path := filepath.Join(t.TempDir(), "save.json")
+	g := newOriginal()
+	for g.TickCount < saveTick && g.State != StateGameOver {
+		g.Step()
+	}
+	if err := g.SaveGameToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	save, err := LoadSaveFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := newTestBattle(t, data, string(AIEasy))
+	if err := resumed.RestoreSnapshot(save); err != nil {
+		t.Fatal(err)
+	}
+	for _, team := range []TeamID{Team1, Team2} {
+		if name, err := teamAIName(baseAI(resumed.teamAI[team])); err != nil || name != string(AIHard) {
+			t.Errorf("%sのAIが %s になりました (%v)", teamLabel(team), name, err)
+		}
+	}
+	if rules, ok := resumed.teamAI[Team2].(*RuleBasedAI); !ok || len(rules.Rules["E-03"]) != 1 {
+		t.Errorf("チーム2の行動ルールが作り直されていません: %T", resumed.teamAI[Team2])
+	}
+	if resumed.autoBattleAI != string(AIHard) {
+		t.Errorf("おまかせのAIが %s になりました", resumed.autoBattleAI)
+	}
+	resumed.runSimulation(maxTicks)
+	if got := battleFingerprint(resumed); got != want {
+		t.Errorf("違うAIで起動したゲームにロードすると結末が変わりました\n再開: %s\n中断なし: %s", got, want)
+	}
+}
+
+// TestRestoreSnapshotChecksExternalBot は外部ボットの有無が保存時と違うとロードを拒み、同じなら今のボットの代わりのAIを差し替えることを確かめる
+func TestRestoreSnapshotChecksExternalBot(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	withBot := func() (*Game, *ExternalBotAI) {
+		g := newTestBattle(t, data, string(AINormal))
+		bot, _, _ := newTestBot(time.Hour)
+		bot.Fallback = g.teamAI[Team2]
+		g.SetTeamAI(Team2, bot)
+		return g, bot
+	}
+
+	botGame, _ := withBot()
+	botSave, err := botGame.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestBattle(t, data, string(AINormal)).RestoreSnapshot(botSave); err == nil {
+		t.Error("外部ボットを使ったセーブデータを、ボットなしで読み込みました")
+	}
+
+	plainSave, err := newTestBattle(t, data, string(AINormal)).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, _ := withBot(); g.RestoreSnapshot(plainSave) == nil {
+		t.Error("外部ボットを使わないセーブデータを、ボットありで読み込みました")
+	}
+
+	g, bot := withBot()
+	botSave.TeamAI[Team2].AI = string(AIEasy)
+	if err := g.RestoreSnapshot(botSave); err != nil {
+		t.Fatalf("同じくボットを使うゲームに読み込めません: %v", err)
+	}
+	if g.teamAI[Team2] != bot {
+		t.Error("外部ボットが差し替えられました")
+	}
+	if name, _ := teamAIName(bot.Fallback); name != string(AIEasy) {
+		t.Errorf("ボットの代わりのAIが %s になりました (%s のはず)", name, AIEasy)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"log"
+)
+
+// cloneForSimulation は先読み用に、現在のバトル状態を UI を持たない別の Game として複製する。
+// メダロットとパーツはディープコピーし、TargetedMedarot や実行キューは複製側のメダロットを指すよう付け替える。
+// 複製ではプレイヤーチームを持たず、両チームとも指定したAIが行動を決める。乱数は元のバトルとは別系列になる。
+// 大量に作られるので、進行ログは quietLogger に捨てる。
+func (g *Game) cloneForSimulation(seed uint64, strategy AIStrategy) *Game {
+	clone := &Game{
+		GameData:        g.GameData,
+		Config:          g.Config,
+		TickCount:       g.TickCount,
+		State:           StatePlaying,
+		BattleMode:      g.BattleMode,
+		PlayerTeam:      TeamNone,
+		winner:          g.winner,
+		teamAI:          map[TeamID]AIStrategy{Team1: strategy, Team2: strategy},
+		autoAcknowledge: true,
+		logger:          quietLogger,
+	}
+	clone.SetSeed(seed)
+
+	remap := make(map[*Medarot]*Medarot, len(g.Medarots))
+	clone.Medarots = make([]*Medarot, 0, len(g.Medarots))
+	for _, m := range g.Medarots {
+		c := *m
+		medal := *m.Medal
+		c.Medal = &medal
+		c.Parts = make(map[PartSlotKey]*Part, len(m.Parts))
+		for slot, part := range m.Parts {
+			p := *part
+			c.Parts[slot] = &p
+		}
+		clone.Medarots = append(clone.Medarots, &c)
+		remap[m] = &c
+	}
+	for _, c := range clone.Medarots {
+		if c.TargetedMedarot != nil {
+			c.TargetedMedarot = remap[c.TargetedMedarot]
+		}
+	}
+	clone.actionQueue = make([]*Medarot, 0, len(g.actionQueue))
+	for _, m := range g.actionQueue {
+		clone.actionQueue = append(clone.actionQueue, remap[m])
+	}
+	clone.initializeMedarotLists()
+	return clone
+}
+
+// runSimulation は決着がつくか maxTicks に達するまでバトルを進め、勝ったチームを返す（未決着なら TeamNone）
+func (g *Game) runSimulation(maxTicks int) TeamID {
+	end := g.TickCount + maxTicks
+	for g.State != StateGameOver && g.TickCount < end {
+		g.Step()
+	}
+	if g.State != StateGameOver {
+		return TeamNone
+	}
+	return g.winner
+}
+
+// quietLogger は何も出力しないロガー。大量の先読みや UI なしのシミュレーションで、
+// ログが溢れないようにバトルの logger に渡す（標準のロガーの出力先は変えない）
+var quietLogger = log.New(io.Discard, "", 0)
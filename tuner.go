@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strings"
+)
+
+// tunableParam は自動調整の対象にする BalanceConfig の数値1つ。
+// 探索は [Min, Max] を 0〜1 に正規化した空間で行う（LogScale なら対数で正規化する）。
+type tunableParam struct {
+	Name     string
+	Min, Max float64
+	Integer  bool
+	LogScale bool
+	get      func(b *BalanceConfig) float64
+	set      func(b *BalanceConfig, v float64)
+}
+
+// tunableParams は自動調整で動かすパラメータの一覧
+var tunableParams = []tunableParam{
+	{Name: "Time.GameSpeedMultiplier", Min: 1, Max: 200, LogScale: true,
+		get: func(b *BalanceConfig) float64 { return b.Time.GameSpeedMultiplier },
+		set: func(b *BalanceConfig, v float64) { b.Time.GameSpeedMultiplier = v }},
+	{Name: "Time.PropulsionEffectRate", Min: 0, Max: 0.05,
+		get: func(b *BalanceConfig) float64 { return b.Time.PropulsionEffectRate },
+		set: func(b *BalanceConfig, v float64) { b.Time.PropulsionEffectRate = v }},
+	{Name: "Hit.BaseChance", Min: 30, Max: 95, Integer: true,
+		get: func(b *BalanceConfig) float64 { return float64(b.Hit.BaseChance) },
+		set: func(b *BalanceConfig, v float64) { b.Hit.BaseChance = int(v) }},
+	{Name: "Hit.TraitAimBonus", Min: 0, Max: 40, Integer: true,
+		get: func(b *BalanceConfig) float64 { return float64(b.Hit.TraitAimBonus) },
+		set: func(b *BalanceConfig, v float64) { b.Hit.TraitAimBonus = int(v) }},
+	{Name: "Hit.TraitStrikeBonus", Min: 0, Max: 40, Integer: true,
+		get: func(b *BalanceConfig) float64 { return float64(b.Hit.TraitStrikeBonus) },
+		set: func(b *BalanceConfig, v float64) { b.Hit.TraitStrikeBonus = int(v) }},
+	{Name: "Hit.TraitBerserkDebuff", Min: -60, Max: 0, Integer: true,
+		get: func(b *BalanceConfig) float64 { return float64(b.Hit.TraitBerserkDebuff) },
+		set: func(b *BalanceConfig, v float64) { b.Hit.TraitBerserkDebuff = int(v) }},
+	{Name: "Damage.CriticalMultiplier", Min: 1, Max: 3,
+		get: func(b *BalanceConfig) float64 { return b.Damage.CriticalMultiplier },
+		set: func(b *BalanceConfig, v float64) { b.Damage.CriticalMultiplier = v }},
+	{Name: "Damage.MedalSkillFactor", Min: 0, Max: 10, Integer: true,
+		get: func(b *BalanceConfig) float64 { return float64(b.Damage.MedalSkillFactor) },
+		set: func(b *BalanceConfig, v float64) { b.Damage.MedalSkillFactor = int(v) }},
+}
+
+func (p *tunableParam) normalize(v float64) float64 {
+	if p.LogScale {
+		return (math.Log(v) - math.Log(p.Min)) / (math.Log(p.Max) - math.Log(p.Min))
+	}
+	return (v - p.Min) / (p.Max - p.Min)
+}
+
+func (p *tunableParam) denormalize(x float64) float64 {
+	x = math.Max(0, math.Min(1, x))
+	var v float64
+	if p.LogScale {
+		v = math.Exp(math.Log(p.Min) + x*(math.Log(p.Max)-math.Log(p.Min)))
+	} else {
+		v = p.Min + x*(p.Max-p.Min)
+	}
+	if p.Integer {
+		return math.Round(v)
+	}
+	return math.Round(v*1000) / 1000
+}
+
+// TuningTargets はデザイナーが決める目標値
+type TuningTargets struct {
+	BattleSeconds    float64 // 平均バトル時間（秒）
+	SecondsTolerance float64
+	MirrorWinRate    float64 // ミラーマッチでのチーム1の勝率
+	WinRateTolerance float64
+	TraitMinRate     float64 // 各特性が全行動に占める割合の下限
+	TraitMaxRate     float64 // 各特性が全行動に占める割合の上限
+}
+
+// TuningEvaluation は1つのバランス設定をシミュレーションで評価した結果
+type TuningEvaluation struct {
+	Balance       BalanceConfig
+	BattleSeconds float64
+	MirrorWinRate float64
+	TraitRates    map[Trait]float64
+	Loss          float64
+}
+
+// balanceTuner はシミュレーションでバランス設定を評価し、目標に近づくように探索する
+type balanceTuner struct {
+	base    SimulationOptions // 通常の対戦（バトル時間と特性の使用率を測る）
+	mirror  SimulationOptions // ミラーマッチ（勝率の偏りを測る）
+	targets TuningTargets
+	traits  []Trait // パーツの中に存在する特性
+}
+
+// evaluate は設定を評価する。すべての候補で同じシードを使うので、乱数ではなく設定の差で比べられる。
+func (t *balanceTuner) evaluate(balance BalanceConfig) (*TuningEvaluation, error) {
+	t.base.Balance = balance
+	t.mirror.Balance = balance
+	baseReport, err := RunSimulations(t.base)
+	if err != nil {
+		return nil, err
+	}
+	mirrorReport, err := RunSimulations(t.mirror)
+	if err != nil {
+		return nil, err
+	}
+	eval := &TuningEvaluation{
+		Balance:       balance,
+		BattleSeconds: baseReport.AvgSeconds,
+		MirrorWinRate: mirrorReport.Team1WinRate,
+		TraitRates:    make(map[Trait]float64),
+	}
+	for _, trait := range t.traits {
+		eval.TraitRates[trait] = 0
+	}
+	for _, ts := range baseReport.Traits {
+		eval.TraitRates[ts.Trait] = ts.UseRate
+	}
+	eval.Loss = t.loss(eval)
+	return eval, nil
+}
+
+// loss は目標からのずれを1つの数値にする。許容範囲内のずれは小さく、範囲外は大きく数える。
+func (t *balanceTuner) loss(e *TuningEvaluation) float64 {
+	tg := t.targets
+	outside := func(diff, tolerance, scale float64) float64 {
+		excess := math.Max(0, math.Abs(diff)-tolerance) / scale
+		near := math.Abs(diff) / scale * 0.1
+		return excess*excess + near*near
+	}
+	loss := outside(e.BattleSeconds-tg.BattleSeconds, tg.SecondsTolerance, math.Max(tg.SecondsTolerance, 1))
+	loss += outside(e.MirrorWinRate-tg.MirrorWinRate, tg.WinRateTolerance, math.Max(tg.WinRateTolerance, 0.01))
+	for _, trait := range t.traits {
+		rate := e.TraitRates[trait]
+		if rate < tg.TraitMinRate {
+			d := (tg.TraitMinRate - rate) / math.Max(tg.TraitMinRate, 0.01)
+			loss += d * d
+		} else if rate > tg.TraitMaxRate {
+			d := (rate - tg.TraitMaxRate) / math.Max(tg.TraitMaxRate, 0.01)
+			loss += d * d
+		}
+	}
+	return loss
+}
+
+// search は現在の設定から始めて、パラメータを1つずつ少しずつ動かし、評価が良くなった変更だけを採用する（山登り法）。
+// 改善しない試行が続くと動かす幅を小さくする。
+func (t *balanceTuner) search(start BalanceConfig, iterations int, rng *rand.Rand, progress io.Writer) (*TuningEvaluation, error) {
+	best, err := t.evaluate(start)
+	if err != nil {
+		return nil, err
+	}
+	step := 0.25
+	failures := 0
+	for i := 0; i < iterations; i++ {
+		candidate := best.Balance
+		p := &tunableParams[rng.IntN(len(tunableParams))]
+		delta := step * (0.5 + rng.Float64())
+		if rng.IntN(2) == 0 {
+			delta = -delta
+		}
+		old := p.get(&candidate)
+		p.set(&candidate, p.denormalize(p.normalize(old)+delta))
+		if p.get(&candidate) == old {
+			continue
+		}
+		eval, err := t.evaluate(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if eval.Loss < best.Loss {
+			fmt.Fprintf(progress, "[%d/%d] %s: %v -> %v (評価 %.4f -> %.4f)\n", i+1, iterations, p.Name, old, p.get(&candidate), best.Loss, eval.Loss)
+			best = eval
+			failures = 0
+			continue
+		}
+		failures++
+		if failures >= 2*len(tunableParams) && step > 0.01 {
+			step /= 2
+			failures = 0
+		}
+	}
+	return best, nil
+}
+
+// runMedatune は "medatune" サブコマンド。シミュレーションで BalanceConfig を目標に合わせて調整し、提案と報告を出力する。
+//
+//	medarot-ebiten medatune -seconds 90 -winrate 0.5 -winrate-tol 0.03 -iterations 200 -out proposed_balance.json
+func runMedatune(args []string) error {
+	fs := flag.NewFlagSet("medatune", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "既定値に重ねる設定ファイル (JSON)")
+	dataDir := fs.String("data", "", "標準データの代わりに読むデータのディレクトリか zip (省略時は埋め込みのデータ)")
+	packs := fs.String("packs", "", "標準データの上に重ねるデータパックのディレクトリか zip (カンマ区切り、後ろほど優先)")
+	battles := fs.Int("n", 200, "1つの設定を評価するバトルの回数")
+	iterations := fs.Int("iterations", 100, "探索の試行回数")
+	seed := fs.Uint64("seed", 1, "バトルと探索のシード")
+	seconds := fs.Float64("seconds", 90, "目標の平均バトル時間（秒）")
+	secondsTol := fs.Float64("seconds-tol", 5, "平均バトル時間の許容誤差（秒）")
+	winRate := fs.Float64("winrate", 0.5, "ミラーマッチでの目標勝率")
+	winRateTol := fs.Float64("winrate-tol", 0.03, "ミラーマッチ勝率の許容誤差")
+	traitMin := fs.Float64("trait-min", 0.05, "各特性が選ばれる割合の下限")
+	traitMax := fs.Float64("trait-max", 0.6, "各特性が選ばれる割合の上限")
+	team1 := fs.String("team1", "", "チーム1のメダロットID（省略時は medarots.csv のチーム0）")
+	team2 := fs.String("team2", "", "チーム2のメダロットID（省略時は medarots.csv のチーム1）")
+	mirror := fs.String("mirror", "", "ミラーマッチに使う編成（省略時はチーム1の編成）")
+	ai := fs.String("ai", string(AINormal), "両チームのAI")
+	mode := fs.String("mode", string(ModeWait), "バトルモード (active, wait, turn)")
+	out := fs.String("out", "proposed_balance.json", "提案するバランス設定の出力先 (設定ファイルと同じ JSON)")
+	report := fs.String("report", "", "報告の出力先（省略時は標準出力）")
+	fs.Parse(args)
+
+	battleMode, err := ParseBattleMode(*mode)
+	if err != nil {
+		return err
+	}
+	gameData, _, err := LoadDataPacks(PackSources(*dataDir, *packs))
+	if err != nil {
+		return err
+	}
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	tuner := &balanceTuner{
+		targets: TuningTargets{
+			BattleSeconds:    *seconds,
+			SecondsTolerance: *secondsTol,
+			MirrorWinRate:    *winRate,
+			WinRateTolerance: *winRateTol,
+			TraitMinRate:     *traitMin,
+			TraitMaxRate:     *traitMax,
+		},
+		traits: partTraits(gameData),
+	}
+	tuner.base = SimulationOptions{GameData: gameData, AI1: *ai, AI2: *ai, Mode: battleMode, Seed: *seed, Battles: *battles}
+	if tuner.base.Team1, err = selectLoadouts(gameData, *team1, Team1); err != nil {
+		return err
+	}
+	if tuner.base.Team2, err = selectLoadouts(gameData, *team2, Team2); err != nil {
+		return err
+	}
+	tuner.mirror = tuner.base
+	if *mirror != "" {
+		if tuner.mirror.Team1, err = selectLoadouts(gameData, *mirror, Team1); err != nil {
+			return err
+		}
+	}
+	tuner.mirror.Team2 = tuner.mirror.Team1
+
+	current := config.Balance
+	before, err := tuner.evaluate(current)
+	if err != nil {
+		return err
+	}
+	after, err := tuner.search(current, *iterations, rand.New(rand.NewPCG(*seed, 2)), os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	// 設定ファイルと同じ形で書き出すので、そのまま -config に渡したり config.json に写したりできる
+	data, err := json.MarshalIndent(struct{ Balance BalanceConfig }{after.Balance}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("バランス設定の変換に失敗: %w", err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("バランス設定の書き込みに失敗: %w", err)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *report != "" {
+		file, err := os.Create(*report)
+		if err != nil {
+			return fmt.Errorf("報告ファイルの作成に失敗: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	writeTuningReport(w, tuner, before, after)
+	fmt.Fprintf(w, "\n提案するバランス設定を %s に書き出しました。\n", *out)
+	return nil
+}
+
+// writeTuningReport は調整前後のパラメータと指標を目標と並べて書き出す
+func writeTuningReport(w io.Writer, tuner *balanceTuner, before, after *TuningEvaluation) {
+	tg := tuner.targets
+	fmt.Fprintf(w, "パラメータ\n")
+	for i := range tunableParams {
+		p := &tunableParams[i]
+		b, a := p.get(&before.Balance), p.get(&after.Balance)
+		mark := ""
+		if a != b {
+			mark = "  *"
+		}
+		fmt.Fprintf(w, "  %-28s %10v -> %-10v%s\n", p.Name, b, a, mark)
+	}
+
+	fmt.Fprintf(w, "\n指標 (調整前 -> 調整後, 目標)\n")
+	fmt.Fprintf(w, "  平均バトル時間      %7.1f秒 -> %7.1f秒  %s (%.0f±%.0f秒)\n",
+		before.BattleSeconds, after.BattleSeconds, okMark(math.Abs(after.BattleSeconds-tg.BattleSeconds) <= tg.SecondsTolerance), tg.BattleSeconds, tg.SecondsTolerance)
+	fmt.Fprintf(w, "  ミラーマッチ勝率    %7.1f%%  -> %7.1f%%   %s (%.0f±%.0f%%)\n",
+		before.MirrorWinRate*100, after.MirrorWinRate*100, okMark(math.Abs(after.MirrorWinRate-tg.MirrorWinRate) <= tg.WinRateTolerance), tg.MirrorWinRate*100, tg.WinRateTolerance*100)
+	for _, trait := range tuner.traits {
+		rate := after.TraitRates[trait]
+		fmt.Fprintf(w, "  特性 %-14s %7.1f%%  -> %7.1f%%   %s (%.0f〜%.0f%%)\n",
+			trait, before.TraitRates[trait]*100, rate*100, okMark(rate >= tg.TraitMinRate && rate <= tg.TraitMaxRate), tg.TraitMinRate*100, tg.TraitMaxRate*100)
+	}
+	fmt.Fprintf(w, "  評価値 (小さいほど良い) %.4f -> %.4f\n", before.Loss, after.Loss)
+}
+
+func okMark(ok bool) string {
+	if ok {
+		return "OK"
+	}
+	return "NG"
+}
+
+// partTraits はパーツの中に存在する攻撃の特性を返す
+func partTraits(gameData *GameData) []Trait {
+	seen := make(map[Trait]bool)
+	var traits []Trait
+	for _, part := range gameData.AllParts {
+		if part.Category == CategoryNone || part.Trait == TraitNone || strings.TrimSpace(string(part.Trait)) == "" {
+			continue
+		}
+		if !seen[part.Trait] {
+			seen[part.Trait] = true
+			traits = append(traits, part.Trait)
+		}
+	}
+	sort.Slice(traits, func(i, j int) bool { return traits[i] < traits[j] })
+	return traits
+}
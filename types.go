@@ -1,7 +1,7 @@
 package main
 
 import (
-	"image/color"
+	"log"
 
 	"github.com/ebitenui/ebitenui/widget"
 )
@@ -14,10 +14,13 @@ type PartSlotKey string
 type PartType string
 type PartCategory string
 type Trait string
+type BattleMode string
 
 const (
 	Team1 TeamID = 0
 	Team2 TeamID = 1
+	// TeamNone はどのチームにも属さない（勝敗のお知らせなど）ことを表す
+	TeamNone TeamID = -1
 )
 const (
 	StateIdle     MedarotState = "待機"
@@ -56,8 +59,19 @@ const (
 	TraitNormal  Trait = "NORMAL"
 	TraitNone    Trait = "NONE"
 )
+const (
+	// ModeActive はプレイヤーの行動選択中もゲージが進み続けるリアルタイム方式
+	ModeActive BattleMode = "active"
+	// ModeWait はプレイヤーの行動選択中に時間を停止する方式
+	ModeWait BattleMode = "wait"
+	// ModeTurn は全員の行動選択後にイニシアチブ順で行動するターン制方式
+	ModeTurn BattleMode = "turn"
+)
 const PlayersPerTeam = 3
 
+// SimulationTicksPerSecond はシミュレーションの固定ティックレート。表示側の TPS とは独立している。
+const SimulationTicksPerSecond = 60
+
 type Config struct {
 	Balance BalanceConfig
 	UI      UIConfig
@@ -85,12 +99,12 @@ type BalanceConfig struct {
 
 // ... (UIConfig, GameData, etc. は変更なし) ...
 type UIConfig struct {
-	Screen struct {
+	Language string // プレイヤーに見せる文字列の言語 (ja, en)。locales/<言語>.json のメッセージを使う
+	Screen   struct {
 		Width  int
 		Height int
 	}
 	Battlefield struct {
-		Rect                   *widget.Container
 		Height                 float32
 		Team1HomeX             float32
 		Team2HomeX             float32
@@ -113,19 +127,22 @@ type UIConfig struct {
 		ButtonHeight  float32
 		ButtonSpacing int
 	}
+	BattleLog struct {
+		Height float32
+	}
 	Colors struct {
-		White      color.Color
-		Red        color.Color
-		Blue       color.Color
-		Yellow     color.Color
-		Gray       color.Color
-		Team1      color.Color
-		Team2      color.Color
-		Leader     color.Color
-		Broken     color.Color
-		HP         color.Color
-		HPCritical color.Color
-		Background color.Color
+		White      HexColor
+		Red        HexColor
+		Blue       HexColor
+		Yellow     HexColor
+		Gray       HexColor
+		Team1      HexColor
+		Team2      HexColor
+		Leader     HexColor
+		Broken     HexColor
+		HP         HexColor
+		HPCritical HexColor
+		Background HexColor
 	}
 }
 type GameData struct {
@@ -136,6 +153,7 @@ type GameData struct {
 type MedarotData struct {
 	ID         string
 	Name       string
+	Names      map[string]string // 別の言語の名前 (name_en 列など)。GameData.Localize で Name に反映する
 	IsLeader   bool
 	Team       TeamID
 	MedalID    string
@@ -176,33 +194,59 @@ type Medarot struct {
 	SelectedPartKey   PartSlotKey
 	TargetedMedarot   *Medarot
 	LastActionLog     string
+	LastActionResult  ActionResult
 	IsEvasionDisabled bool
 	IsDefenseDisabled bool
 	DrawIndex         int
 	ProgressCounter   float64
 	TotalDuration     float64
+	IdleTicks         int
+	logger            *log.Logger // 行動のログの出力先（所属するバトルの logger と同じ）
+}
+// ActionResult は1回の行動の結果。バトルログや統計で使う
+type ActionResult struct {
+	ActorID        string
+	PartID         string
+	PartName       string
+	Trait          Trait
+	TargetID       string
+	Executed       bool // ターゲットが行動不能でなく、命中判定まで進んだか
+	Hit            bool
+	Critical       bool
+	Damage         int
+	TargetPartID   string
+	TargetPartName string
+	TargetPartType PartType
+	PartBroken     bool
 }
 type Part struct {
-	ID         string
-	PartName   string
-	Type       PartType
-	Category   PartCategory
-	Trait      Trait
-	Armor      int
-	MaxArmor   int
-	Power      int
-	Accuracy   int
-	Charge     int
-	Cooldown   int
-	Propulsion int
-	Mobility   int
-	Defense    int
-	
-	IsBroken   bool
+	ID          string
+	PartName    string
+	Names       map[string]string // 別の言語の名前 (part_name_en 列など)
+	Type        PartType
+	Category    PartCategory
+	Trait       Trait
+	Armor       int
+	MaxArmor    int
+	Power       int
+	Accuracy    int
+	Charge      int
+	Cooldown    int
+	Propulsion  int
+	Mobility    int
+	Defense     int
+	TraitParams TraitParams // パーツごとの特性の効果 (parts の trait_params 列)
+	IsBroken    bool
+}
+
+// TraitParams は特性の効果をパーツごとに変える値。nil の項目は設定ファイル (Balance) の値を使う
+type TraitParams struct {
+	HitBonus *int // 命中率の補正（%）。特性ごとの Balance.Hit.Trait*Bonus の代わりに使う
 }
 type Medal struct {
 	ID         string
 	Name       string
+	Names      map[string]string // 別の言語の名前 (name_en 列など)
 	SkillLevel int
 }
 type infoPanelUI struct {
@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	"time"
+
 	"github.com/ebitenui/ebitenui"
 	"github.com/ebitenui/ebitenui/widget"
 )
@@ -13,8 +15,15 @@ type UI struct {
 	messageWindow     widget.PreferredSizeLocateableWidget
 	battlefieldWidget *BattlefieldWidget
 	medarotInfoPanels map[string]*infoPanelUI
+	menuBar           *menuBarUI
+	battleLog         *battleLogPanelUI
+	loadoutEditor     *loadoutEditorUI
+	toastExpiresAt    time.Time
 }
 
+// toastDuration はトースト通知を表示しておく時間
+const toastDuration = 3 * time.Second
+
 // NewUI はUIを構築し、管理構造体を返す
 func NewUI(game *Game) *UI {
 	ui := &UI{
@@ -52,13 +61,27 @@ func NewUI(game *Game) *UI {
 	)
 	mainUIContainer.AddChild(team1PanelContainer)
 
+	// 中央の列: バトルフィールドとその下のバトルログ
+	centerColumn := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(1),
+			widget.GridLayoutOpts.Stretch([]bool{true}, []bool{true, false}),
+			widget.GridLayoutOpts.Spacing(0, game.Config.UI.InfoPanel.Padding),
+		)),
+	)
+	mainUIContainer.AddChild(centerColumn)
+
 	// バトルフィールドウィジェット
 	ui.battlefieldWidget = NewBattlefieldWidget(game)
 	ui.battlefieldWidget.Container.GetWidget().LayoutData = widget.GridLayoutData{
 		HorizontalPosition: widget.GridLayoutPositionCenter,
 		VerticalPosition:   widget.GridLayoutPositionCenter,
 	}
-	mainUIContainer.AddChild(ui.battlefieldWidget.Container)
+	centerColumn.AddChild(ui.battlefieldWidget.Container)
+
+	// バトルログパネル
+	ui.battleLog = createBattleLogPanel(game)
+	centerColumn.AddChild(ui.battleLog.rootContainer)
 
 	// チーム2の情報パネルのコンテナ
 	team2PanelContainer := widget.NewContainer(
@@ -88,6 +111,10 @@ func NewUI(game *Game) *UI {
 		}
 	}
 
+	// メニューバー（セーブ/ロードなど）は画面上部に重ねて表示する
+	ui.menuBar = createMenuBar(game)
+	rootContainer.AddChild(ui.menuBar.rootContainer)
+
 	ui.ebitenui = &ebitenui.UI{
 		Container: rootContainer,
 	}
@@ -135,3 +162,16 @@ func (u *UI) HideMessageWindow() {
 		u.messageWindow = nil
 	}
 }
+
+// ShowToast はメニューバーの下に短いお知らせを一定時間表示する
+func (u *UI) ShowToast(msg string) {
+	u.menuBar.toastText.Label = msg
+	u.toastExpiresAt = time.Now().Add(toastDuration)
+}
+
+// updateToast は表示時間を過ぎたトースト通知を消す
+func (u *UI) updateToast() {
+	if u.menuBar.toastText.Label != "" && time.Now().After(u.toastExpiresAt) {
+		u.menuBar.toastText.Label = ""
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"image/color"
 	"log"
 
@@ -34,7 +33,7 @@ func createActionModalUI(game *Game, actingMedarot *Medarot) widget.PreferredSiz
 	)
 	overlay.AddChild(panel)
 	panel.AddChild(widget.NewText(
-		widget.TextOpts.Text(fmt.Sprintf("行動選択: %s", actingMedarot.Name), game.MplusFont, c.Colors.White),
+		widget.TextOpts.Text(T("action.title", "name", actingMedarot.Name), game.MplusFont, c.Colors.White),
 	))
 	buttonImage := &widget.ButtonImage{
 		Idle:    image.NewNineSliceColor(c.Colors.Gray),
@@ -44,7 +43,7 @@ func createActionModalUI(game *Game, actingMedarot *Medarot) widget.PreferredSiz
 	availableParts := actingMedarot.GetAvailableAttackParts()
 	if len(availableParts) == 0 {
 		panel.AddChild(widget.NewText(
-			widget.TextOpts.Text("利用可能なパーツがありません。", game.MplusFont, c.Colors.White),
+			widget.TextOpts.Text(T("action.no_parts"), game.MplusFont, c.Colors.White),
 		))
 	}
 	for _, part := range availableParts {
@@ -54,7 +53,7 @@ func createActionModalUI(game *Game, actingMedarot *Medarot) widget.PreferredSiz
 				Stretch: true,
 			})),
 			widget.ButtonOpts.Image(buttonImage),
-			widget.ButtonOpts.Text(fmt.Sprintf("%s (%s)", capturedPart.PartName, capturedPart.Category), game.MplusFont, &widget.ButtonTextColor{
+			widget.ButtonOpts.Text(T("action.part", "part", capturedPart.PartName, "category", T("category."+string(capturedPart.Category))), game.MplusFont, &widget.ButtonTextColor{
 				Idle: c.Colors.White,
 			}),
 			widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(5)),
@@ -64,19 +63,31 @@ func createActionModalUI(game *Game, actingMedarot *Medarot) widget.PreferredSiz
 		)
 		panel.AddChild(actionButton)
 	}
+	autoButton := widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+			Stretch: true,
+		})),
+		widget.ButtonOpts.Image(buttonImage),
+		widget.ButtonOpts.Text(T("action.auto"), game.MplusFont, &widget.ButtonTextColor{
+			Idle: c.Colors.White,
+		}),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(5)),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			game.SetAutoBattle(actingMedarot, true)
+		}),
+	)
+	panel.AddChild(autoButton)
 	cancelButton := widget.NewButton(
 		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{
 			Stretch: true,
 		})),
 		widget.ButtonOpts.Image(buttonImage),
-		widget.ButtonOpts.Text("キャンセル", game.MplusFont, &widget.ButtonTextColor{
+		widget.ButtonOpts.Text(T("action.cancel"), game.MplusFont, &widget.ButtonTextColor{
 			Idle: c.Colors.White,
 		}),
 		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(5)),
 		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
-			game.ui.HideActionModal()
-			game.playerMedarotToAct = nil
-			game.State = StatePlaying
+			game.finishPlayerSelection()
 		}),
 	)
 	panel.AddChild(cancelButton)
@@ -104,11 +115,8 @@ func handleActionSelection(game *Game, actingMedarot *Medarot, selectedPart *Par
 			target = candidates[0]
 		}
 	} else {
-		game.enqueueMessage("ターゲットがいません！", func() {
-			game.playerMedarotToAct = nil
-			game.State = StatePlaying
-		})
-		game.ui.HideActionModal()
+		game.finishPlayerSelection()
+		game.enqueueMessage(T("battle.no_target"), nil)
 		return
 	}
 
@@ -120,17 +128,13 @@ func handleActionSelection(game *Game, actingMedarot *Medarot, selectedPart *Par
 		}
 	}
 
-	if actingMedarot.SelectAndStartCharge(slotKey, target, &game.Config.Balance) {
-		game.ui.HideActionModal()
-		game.playerMedarotToAct = nil
-		game.State = StatePlaying
+	if game.commitAction(actingMedarot, slotKey, target) {
+		game.finishPlayerSelection()
 		game.processIdleMedarots()
 	} else {
 		log.Printf("エラー: %s の行動選択に失敗しました。", actingMedarot.Name)
-		game.ui.HideActionModal()
-		game.playerMedarotToAct = nil
-		game.State = StatePlaying
+		game.finishPlayerSelection()
 	}
 }
 
-// [REMOVED] showUIActionModal と hideUIActionModal を削除
\ No newline at end of file
+// [REMOVED] showUIActionModal と hideUIActionModal を削除
\ No newline at end of file
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/ebitenui/ebitenui/image"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+// battleLogMaxLines はパネルに表示するログの最大行数。古い行から消す（ログ全体は書き出しで残る）
+const battleLogMaxLines = 200
+
+// battleLogPanelUI はバトルフィールド下のスクロール可能なバトルログ。
+// widget.List は全項目を同じ色で描くのでチームごとの色分けができず、項目の選択も要らないため、
+// BBCode で行ごとに色を付けられる TextArea を使う。TextArea は行が増えるたびに全文を測り直すので、表示する行数に上限を設ける。
+type battleLogPanelUI struct {
+	rootContainer    *widget.Container
+	textArea         *widget.TextArea
+	damageOnlyButton *widget.Button
+	myTeamOnlyButton *widget.Button
+	filter           battleLogFilter
+	lines            []string // 表示中の行（BBCode 付き）
+}
+
+func createBattleLogPanel(game *Game) *battleLogPanelUI {
+	c := game.Config.UI
+	panel := &battleLogPanelUI{}
+
+	panel.rootContainer = widget.NewContainer(
+		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(color.NRGBA{20, 20, 30, 220})),
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(1),
+			widget.GridLayoutOpts.Stretch([]bool{true}, []bool{false, true}),
+			widget.GridLayoutOpts.Padding(widget.NewInsetsSimple(5)),
+			widget.GridLayoutOpts.Spacing(0, 4),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.MinSize(0, int(c.BattleLog.Height)),
+		),
+	)
+
+	// ヘッダー: フィルタと書き出しボタン
+	header := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(c.ActionModal.ButtonSpacing),
+		)),
+	)
+	panel.rootContainer.AddChild(header)
+
+	header.AddChild(widget.NewText(
+		widget.TextOpts.Text(T("log.title"), game.MplusFont, c.Colors.White),
+		widget.TextOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+			Position: widget.RowLayoutPositionCenter,
+		})),
+	))
+	panel.damageOnlyButton = newMenuButton(game, "", func() {
+		panel.filter.damageOnly = !panel.filter.damageOnly
+		panel.rebuild(game)
+	})
+	header.AddChild(panel.damageOnlyButton)
+	panel.myTeamOnlyButton = newMenuButton(game, "", func() {
+		panel.filter.myTeamOnly = !panel.filter.myTeamOnly
+		panel.rebuild(game)
+	})
+	header.AddChild(panel.myTeamOnlyButton)
+	for _, ext := range []string{".txt", ".json"} {
+		ext := ext
+		header.AddChild(newMenuButton(game, T("log.export", "format", ext[1:]), func() {
+			path, err := game.ExportBattleLog(ext)
+			if err != nil {
+				log.Printf("バトルログの書き出しに失敗しました: %v", err)
+				game.ui.ShowToast(T("log.export_failed", "error", err))
+				return
+			}
+			game.ui.ShowToast(T("log.exported", "path", path))
+		}))
+	}
+
+	panel.textArea = widget.NewTextArea(
+		widget.TextAreaOpts.ContainerOpts(widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				HorizontalPosition: widget.GridLayoutPositionStart,
+				VerticalPosition:   widget.GridLayoutPositionStart,
+			}),
+		)),
+		widget.TextAreaOpts.ScrollContainerOpts(widget.ScrollContainerOpts.Image(&widget.ScrollContainerImage{
+			Idle: image.NewNineSliceColor(color.NRGBA{10, 10, 20, 255}),
+			Mask: image.NewNineSliceColor(color.NRGBA{10, 10, 20, 255}),
+		})),
+		widget.TextAreaOpts.SliderOpts(
+			widget.SliderOpts.Images(
+				&widget.SliderTrackImage{
+					Idle:  image.NewNineSliceColor(color.NRGBA{40, 40, 50, 255}),
+					Hover: image.NewNineSliceColor(color.NRGBA{40, 40, 50, 255}),
+				},
+				&widget.ButtonImage{
+					Idle:    image.NewNineSliceColor(c.Colors.Gray),
+					Hover:   image.NewNineSliceColor(color.RGBA{180, 180, 180, 255}),
+					Pressed: image.NewNineSliceColor(color.RGBA{100, 100, 100, 255}),
+				},
+			),
+			widget.SliderOpts.MinHandleSize(10),
+		),
+		widget.TextAreaOpts.ShowVerticalScrollbar(),
+		widget.TextAreaOpts.VerticalScrollMode(widget.ScrollEnd),
+		widget.TextAreaOpts.ProcessBBCode(true),
+		widget.TextAreaOpts.FontFace(game.MplusFont),
+		widget.TextAreaOpts.FontColor(c.Colors.White),
+		widget.TextAreaOpts.TextPadding(widget.NewInsetsSimple(4)),
+	)
+	panel.rootContainer.AddChild(panel.textArea)
+
+	panel.rebuild(game)
+	return panel
+}
+
+// appendEntry はフィルタに合うエントリだけを末尾に追加する
+func (p *battleLogPanelUI) appendEntry(game *Game, entry *BattleLogEntry) {
+	if !p.filter.matches(entry, game.PlayerTeam) {
+		return
+	}
+	line := formatBattleLogLine(game, entry)
+	if len(p.lines) >= battleLogMaxLines {
+		p.lines = append(p.lines[1:], line)
+		p.textArea.SetText(strings.Join(p.lines, "\n"))
+		return
+	}
+	if len(p.lines) > 0 {
+		p.textArea.AppendText("\n" + line)
+	} else {
+		p.textArea.AppendText(line)
+	}
+	p.lines = append(p.lines, line)
+}
+
+// rebuild はフィルタの変更時などに、ログ全体から表示内容を作り直す
+func (p *battleLogPanelUI) rebuild(game *Game) {
+	p.damageOnlyButton.Text().Label = onOffLabel(T("log.damage_only"), p.filter.damageOnly)
+	p.myTeamOnlyButton.Text().Label = onOffLabel(T("log.my_team_only"), p.filter.myTeamOnly)
+	p.lines = nil
+	for _, entry := range game.battleLog {
+		if p.filter.matches(entry, game.PlayerTeam) {
+			p.lines = append(p.lines, formatBattleLogLine(game, entry))
+		}
+	}
+	if len(p.lines) > battleLogMaxLines {
+		p.lines = p.lines[len(p.lines)-battleLogMaxLines:]
+	}
+	p.textArea.SetText(strings.Join(p.lines, "\n"))
+}
+
+// formatBattleLogLine はチームごとに色分けした1行を BBCode で返す
+func formatBattleLogLine(game *Game, entry *BattleLogEntry) string {
+	colors := game.Config.UI.Colors
+	lineColor := colors.Yellow
+	switch entry.Team {
+	case Team1:
+		lineColor = colors.Team1
+	case Team2:
+		lineColor = colors.Team2
+	}
+	return fmt.Sprintf("[color=%s]%s[/color]", colorToHex(lineColor), escapeBBCode(entry.FormatText()))
+}
+
+// bbcodeEscaper は本文に含まれる BBCode の色タグを全角の "［" で始まる文字列に置き換える。
+// ebitenui の BBCode にはエスケープが無く、データパックの名前に "[/color]" などがあると以降の色付けが崩れるため
+var bbcodeEscaper = strings.NewReplacer("[color=", "［color=", "[/color]", "［/color]")
+
+// escapeBBCode は本文を BBCode の色タグとして解釈されない文字列にする
+func escapeBBCode(text string) string {
+	return bbcodeEscaper.Replace(text)
+}
+
+// onOffLabel はトグルボタンのラベルを返す
+func onOffLabel(label string, on bool) string {
+	if on {
+		return T("toggle.on", "label", label)
+	}
+	return T("toggle.off", "label", label)
+}
+
+// colorToHex は色を "#RRGGBB" 形式の文字列に変換する
+func colorToHex(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// ebitenuiBBCode は ebitenui が色タグとして解釈する書き方 (widget/text.go の bbcodeRegEx と同じ)
+var ebitenuiBBCode = regexp.MustCompile(`\[color=#[0-9a-fA-F]{6}\]|\[\/color\]`)
+
+// TestFormatBattleLogLineEscapesBBCode は名前に色タグが含まれていても、行の色タグが最初と最後の1組だけになることを確かめる
+func TestFormatBattleLogLineEscapesBBCode(t *testing.T) {
+	data, err := LoadAllGameData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestBattle(t, data, string(AINormal))
+	entry := &BattleLogEntry{Team: Team1, Kind: LogKindAction, Text: "ロボ[/color][color=#ff0000]ル の攻撃"}
+	line := formatBattleLogLine(g, entry)
+	tags := ebitenuiBBCode.FindAllStringIndex(line, -1)
+	if len(tags) != 2 || tags[0][0] != 0 || tags[1][1] != len(line) {
+		t.Errorf("本文の色タグがエスケープされていません: %s", line)
+	}
+}
@@ -36,7 +36,7 @@ func createSingleMedarotInfoPanel(game *Game, medarot *Medarot) *infoPanelUI {
 	headerContainer.AddChild(nameText)
 
 	stateText := widget.NewText(
-		widget.TextOpts.Text(string(medarot.State), game.MplusFont, c.Colors.Yellow),
+		widget.TextOpts.Text(medarot.State.Label(), game.MplusFont, c.Colors.Yellow),
 		widget.TextOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.GridLayoutData{
 			HorizontalPosition: widget.GridLayoutPositionEnd,
 		})),
@@ -116,7 +116,7 @@ func updateAllInfoPanels(game *Game) {
 
 func updateSingleInfoPanel(medarot *Medarot, ui *infoPanelUI, config *Config) {
 	c := config.UI
-	ui.stateText.Label = string(medarot.State)
+	ui.stateText.Label = medarot.State.Label()
 
 	if medarot.IsLeader {
 		ui.nameText.Color = c.Colors.Leader
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/ebitenui/ebitenui/image"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+// loadoutSlotKeys は編成画面の行の並び（メダル以外）に対応するパーツのスロット
+var loadoutSlotKeys = map[loadoutGene]PartSlotKey{
+	geneHead:     PartSlotHead,
+	geneRightArm: PartSlotRightArm,
+	geneLeftArm:  PartSlotLeftArm,
+	geneLegs:     PartSlotLegs,
+}
+
+// loadoutEditorUI は編成画面（メダロッターのセッティング）。プレイヤーのチームの編成を下書きとして書き換え、
+// 「この編成で開始」でバトルをやり直し、「保存」で編成ファイルに書き出す。開いている間はバトルを止める
+type loadoutEditorUI struct {
+	game         *Game
+	pool         *loadoutPool
+	draft        []MedarotData // プレイヤーのチームの編成（draw_index の順）
+	selected     int
+	overlay      *widget.Container
+	tabButtons   []*widget.Button
+	slotTexts    [geneCount]*widget.Text
+	leaderButton *widget.Button
+	summaryText  *widget.Text
+	chargeText   *widget.Text
+	statusText   *widget.Text
+}
+
+// createLoadoutEditor は編成画面を組み立てる。メダルかパーツの候補が無い部位があればエラーを返す
+func createLoadoutEditor(game *Game) (*loadoutEditorUI, error) {
+	pool, err := newLoadoutPool(game.GameData)
+	if err != nil {
+		return nil, err
+	}
+	e := &loadoutEditorUI{
+		game:  game,
+		pool:  pool,
+		draft: teamLoadouts(game.GameData, game.PlayerTeam),
+	}
+	if len(e.draft) == 0 {
+		return nil, errors.New(T("loadout.no_members", "team", teamLabel(game.PlayerTeam)))
+	}
+	c := game.Config.UI
+	e.overlay = widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewAnchorLayout()),
+		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(color.NRGBA{0, 0, 0, 180})),
+	)
+	panel := widget.NewContainer(
+		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(color.NRGBA{20, 20, 30, 255})),
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Spacing(c.ActionModal.ButtonSpacing),
+			widget.RowLayoutOpts.Padding(widget.NewInsetsSimple(15)),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.AnchorLayoutData{
+				HorizontalPosition: widget.AnchorLayoutPositionCenter,
+				VerticalPosition:   widget.AnchorLayoutPositionCenter,
+			}),
+		),
+	)
+	e.overlay.AddChild(panel)
+	panel.AddChild(e.newText(T("loadout.title", "team", teamLabel(game.PlayerTeam)), c.Colors.White))
+
+	// メダロットごとのタブ
+	tabs := e.newRow()
+	for i := range e.draft {
+		index := i
+		button := newMenuButton(game, "", func() {
+			e.selected = index
+			e.refresh()
+		})
+		e.tabButtons = append(e.tabButtons, button)
+		tabs.AddChild(button)
+	}
+	panel.AddChild(tabs)
+
+	// 部位ごとに ◀ / ▶ で候補を切り替える
+	grid := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(4),
+			widget.GridLayoutOpts.Stretch([]bool{false, false, true, false}, nil),
+			widget.GridLayoutOpts.Spacing(c.ActionModal.ButtonSpacing, c.ActionModal.ButtonSpacing),
+		)),
+	)
+	for gene := loadoutGene(0); gene < geneCount; gene++ {
+		g := gene
+		grid.AddChild(e.newText(g.label(), c.Colors.White))
+		grid.AddChild(newMenuButton(game, "◀", func() { e.cycle(g, -1) }))
+		e.slotTexts[g] = widget.NewText(
+			widget.TextOpts.Text("", game.MplusFont, c.Colors.White),
+			widget.TextOpts.WidgetOpts(widget.WidgetOpts.MinSize(int(c.ActionModal.ButtonWidth)*2, 0)),
+		)
+		grid.AddChild(e.slotTexts[g])
+		grid.AddChild(newMenuButton(game, "▶", func() { e.cycle(g, 1) }))
+	}
+	panel.AddChild(grid)
+
+	e.summaryText = e.newText("", c.Colors.Yellow)
+	panel.AddChild(e.summaryText)
+	e.chargeText = e.newText("", c.Colors.Yellow)
+	panel.AddChild(e.chargeText)
+
+	buttons := e.newRow()
+	e.leaderButton = newMenuButton(game, "", e.makeLeader)
+	buttons.AddChild(e.leaderButton)
+	// リプレイの再生中は記録どおりの編成で再シミュレーションするので、編成を変えて始め直せない
+	startButton := newMenuButton(game, T("loadout.start"), e.start)
+	startButton.GetWidget().Disabled = game.replay != nil
+	buttons.AddChild(startButton)
+	buttons.AddChild(newMenuButton(game, T("loadout.save"), e.save))
+	buttons.AddChild(newMenuButton(game, T("loadout.cancel"), func() {
+		game.ui.HideLoadoutEditor()
+	}))
+	panel.AddChild(buttons)
+
+	e.statusText = e.newText("", c.Colors.White)
+	panel.AddChild(e.statusText)
+	e.refresh()
+	return e, nil
+}
+
+// newText は編成画面の1行のテキストを作る
+func (e *loadoutEditorUI) newText(label string, clr color.Color) *widget.Text {
+	return widget.NewText(widget.TextOpts.Text(label, e.game.MplusFont, clr))
+}
+
+// newRow はボタンを横に並べるコンテナを作る
+func (e *loadoutEditorUI) newRow() *widget.Container {
+	return widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(e.game.Config.UI.ActionModal.ButtonSpacing),
+		)),
+	)
+}
+
+// cycle は選んでいるメダロットの gene の位置を、候補の中で delta 個先のものに替える
+func (e *loadoutEditorUI) cycle(gene loadoutGene, delta int) {
+	candidates := e.pool[gene]
+	m := &e.draft[e.selected]
+	current := -1
+	for i, id := range candidates {
+		if id == gene.get(m) {
+			current = i
+		}
+	}
+	next := (current + delta + len(candidates)) % len(candidates)
+	if current < 0 && delta < 0 {
+		next = len(candidates) - 1
+	}
+	gene.set(m, candidates[next])
+	e.statusText.Label = ""
+	e.refresh()
+}
+
+// makeLeader は選んでいるメダロットをチームのリーダーにする（リーダーはちょうど1体）
+func (e *loadoutEditorUI) makeLeader() {
+	for i := range e.draft {
+		e.draft[i].IsLeader = i == e.selected
+	}
+	e.refresh()
+}
+
+// start は下書きの編成でバトルを最初からやり直す。編成に問題があればバトルはそのままにして理由を表示する
+func (e *loadoutEditorUI) start() {
+	if err := e.game.ApplyLoadouts(e.draft); err != nil {
+		log.Printf("編成を適用できません: %v", err)
+		e.statusText.Label = T("loadout.start_failed", "error", err)
+		return
+	}
+	e.game.ui.ShowToast(T("loadout.started"))
+}
+
+// save は下書きの編成を編成ファイルに書き出す（バトルはそのまま）
+func (e *loadoutEditorUI) save() {
+	if err := SaveLoadoutFile(e.game.LoadoutPath, e.draft); err != nil {
+		log.Printf("編成の保存に失敗しました: %v", err)
+		e.statusText.Label = T("loadout.save_failed", "error", err)
+		return
+	}
+	e.statusText.Label = T("loadout.saved", "path", e.game.LoadoutPath)
+}
+
+// refresh は下書きに合わせてタブ・各部位の名前・能力値のまとめを表示し直す
+func (e *loadoutEditorUI) refresh() {
+	for i, button := range e.tabButtons {
+		label := e.draft[i].Name
+		if e.draft[i].IsLeader {
+			label = T("loadout.leader_tab", "name", label)
+		}
+		if i == e.selected {
+			label = "[" + label + "]"
+		}
+		button.Text().Label = label
+	}
+	m := e.draft[e.selected]
+	stats := computeLoadoutStats(e.game.GameData, m, &e.game.Config.Balance)
+	if medal := findMedalByID(e.game.GameData.Medals, m.MedalID); medal != nil {
+		e.slotTexts[geneMedal].Label = T("loadout.medal", "name", medal.Name, "level", medal.SkillLevel)
+	} else {
+		e.slotTexts[geneMedal].Label = T("medal.fallback")
+	}
+	for gene, slot := range loadoutSlotKeys {
+		part := stats.Parts[slot]
+		if slot == PartSlotLegs {
+			e.slotTexts[gene].Label = T("loadout.legs_part", "name", part.PartName, "armor", part.Armor,
+				"propulsion", part.Propulsion, "mobility", part.Mobility)
+		} else {
+			e.slotTexts[gene].Label = T("loadout.attack_part", "name", part.PartName, "armor", part.Armor,
+				"power", part.Power, "category", T("category."+string(part.Category)))
+		}
+	}
+	e.summaryText.Label = T("loadout.summary", "armor", stats.Armor, "propulsion", stats.Propulsion, "mobility", stats.Mobility)
+	seconds := func(slot PartSlotKey) string { return fmt.Sprintf("%.1f", stats.Charge[slot]) }
+	e.chargeText.Label = T("loadout.charge", "head", seconds(PartSlotHead), "r_arm", seconds(PartSlotRightArm), "l_arm", seconds(PartSlotLeftArm))
+	if m.IsLeader {
+		e.leaderButton.Text().Label = T("loadout.is_leader")
+	} else {
+		e.leaderButton.Text().Label = T("loadout.make_leader")
+	}
+}
+
+// ShowLoadoutEditor は編成画面を開く。行動選択やメッセージの上に重ね、閉じるまでバトルを止める
+func (u *UI) ShowLoadoutEditor(game *Game) {
+	if u.loadoutEditor != nil {
+		return
+	}
+	editor, err := createLoadoutEditor(game)
+	if err != nil {
+		log.Printf("編成画面を開けません: %v", err)
+		u.ShowToast(T("loadout.unavailable", "error", err))
+		return
+	}
+	u.loadoutEditor = editor
+	u.ebitenui.Container.AddChild(editor.overlay)
+}
+
+// HideLoadoutEditor は編成画面を閉じる。下書きは捨てる
+func (u *UI) HideLoadoutEditor() {
+	if u.loadoutEditor != nil {
+		u.ebitenui.Container.RemoveChild(u.loadoutEditor.overlay)
+		u.loadoutEditor = nil
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/ebitenui/ebitenui/image"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+// menuBarUI は画面上部のメニューバーと、そこに表示するトースト通知を保持する
+type menuBarUI struct {
+	rootContainer *widget.Container
+	buttonRow     *widget.Container
+	toastText     *widget.Text
+	replay        *replayControlsUI
+	autoBattle    *autoBattleControlsUI
+}
+
+// autoBattleControlsUI は「おまかせ」の切り替えボタン（チーム全員・AIの種類・メダロットごと）
+type autoBattleControlsUI struct {
+	teamButton     *widget.Button
+	aiButton       *widget.Button
+	medarotButtons map[*Medarot]*widget.Button
+}
+
+// replayControlsUI はリプレイ再生中に表示する操作ボタンと再生位置の表示
+type replayControlsUI struct {
+	pauseButton       *widget.Button
+	fastForwardButton *widget.Button
+	statusText        *widget.Text
+}
+
+func createMenuBar(game *Game) *menuBarUI {
+	c := game.Config.UI
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewAnchorLayout(
+			widget.AnchorLayoutOpts.Padding(widget.NewInsetsSimple(5)),
+		)),
+	)
+
+	column := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Spacing(4),
+		)),
+		widget.ContainerOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.AnchorLayoutData{
+			HorizontalPosition: widget.AnchorLayoutPositionCenter,
+			VerticalPosition:   widget.AnchorLayoutPositionStart,
+		})),
+	)
+	root.AddChild(column)
+
+	buttonRow := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(c.ActionModal.ButtonSpacing),
+		)),
+		widget.ContainerOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+			Position: widget.RowLayoutPositionCenter,
+		})),
+	)
+	column.AddChild(buttonRow)
+
+	if game.replay != nil {
+		replayControls := addReplayControls(game, buttonRow, column)
+		toastText := widget.NewText(
+			widget.TextOpts.Text("", game.MplusFont, c.Colors.Yellow),
+			widget.TextOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionCenter,
+			})),
+		)
+		column.AddChild(toastText)
+		return &menuBarUI{
+			rootContainer: root,
+			buttonRow:     buttonRow,
+			toastText:     toastText,
+			replay:        replayControls,
+		}
+	}
+
+	buttonRow.AddChild(newMenuButton(game, T("menu.save"), func() {
+		if err := game.SaveGameToFile(game.SaveFilePath); err != nil {
+			log.Printf("セーブに失敗しました: %v", err)
+			game.ui.ShowToast(T("menu.save_failed", "error", err))
+			return
+		}
+		game.ui.ShowToast(T("menu.saved", "path", game.SaveFilePath))
+	}))
+	buttonRow.AddChild(newMenuButton(game, T("menu.load"), func() {
+		save, err := LoadSaveFile(game.SaveFilePath)
+		if err == nil {
+			err = game.RestoreSnapshot(save)
+		}
+		if err != nil {
+			log.Printf("ロードに失敗しました: %v", err)
+			game.ui.ShowToast(T("menu.load_failed", "error", err))
+			return
+		}
+		game.ui.ShowToast(T("menu.loaded", "path", game.SaveFilePath))
+	}))
+	buttonRow.AddChild(newMenuButton(game, T("menu.save_replay"), func() {
+		path, err := game.SaveReplayRecording()
+		if err != nil {
+			log.Printf("リプレイの保存に失敗しました: %v", err)
+			game.ui.ShowToast(T("menu.replay_save_failed", "error", err))
+			return
+		}
+		game.ui.ShowToast(T("menu.replay_saved", "path", path))
+	}))
+	buttonRow.AddChild(newMenuButton(game, T("menu.loadout"), func() {
+		game.ui.ShowLoadoutEditor(game)
+	}))
+	autoBattle := addAutoBattleControls(game, buttonRow)
+
+	toastText := widget.NewText(
+		widget.TextOpts.Text("", game.MplusFont, c.Colors.Yellow),
+		widget.TextOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+			Position: widget.RowLayoutPositionCenter,
+		})),
+	)
+	column.AddChild(toastText)
+
+	return &menuBarUI{
+		rootContainer: root,
+		buttonRow:     buttonRow,
+		toastText:     toastText,
+		autoBattle:    autoBattle,
+	}
+}
+
+// addAutoBattleControls は「おまかせ」の切り替えボタンを並べる
+func addAutoBattleControls(game *Game, buttonRow *widget.Container) *autoBattleControlsUI {
+	controls := &autoBattleControlsUI{medarotButtons: make(map[*Medarot]*widget.Button)}
+	controls.teamButton = newMenuButton(game, "", func() {
+		game.SetTeamAutoBattle(!game.isTeamAutoBattle())
+	})
+	buttonRow.AddChild(controls.teamButton)
+	controls.aiButton = newMenuButton(game, "", func() {
+		name := game.nextAutoBattleAI()
+		if err := game.SetAutoBattleAI(name); err != nil {
+			log.Printf("おまかせAIの切り替えに失敗しました: %v", err)
+			return
+		}
+		game.ui.ShowToast(T("menu.auto_ai_changed", "ai", name))
+	})
+	buttonRow.AddChild(controls.aiButton)
+	for _, m := range game.sortedMedarotsForDraw {
+		if m.Team != game.PlayerTeam {
+			continue
+		}
+		medarot := m
+		button := newMenuButton(game, "", func() {
+			game.SetAutoBattle(medarot, !game.isAutoBattle(medarot))
+		})
+		controls.medarotButtons[medarot] = button
+		buttonRow.AddChild(button)
+	}
+	updateAutoBattleControls(game, controls)
+	return controls
+}
+
+// updateAutoBattleControls はボタンの表示を現在の「おまかせ」の状態に合わせる
+func updateAutoBattleControls(game *Game, controls *autoBattleControlsUI) {
+	controls.teamButton.Text().Label = onOffLabel(T("menu.auto_all"), game.isTeamAutoBattle())
+	controls.aiButton.Text().Label = T("menu.ai", "ai", game.autoBattleAI)
+	for m, button := range controls.medarotButtons {
+		if game.isAutoBattle(m) {
+			button.Text().Label = T("menu.medarot_auto", "name", m.Name)
+		} else {
+			button.Text().Label = T("menu.medarot_manual", "name", m.Name)
+		}
+	}
+}
+
+// addReplayControls はリプレイ再生用のボタン（最初から・巻き戻し・一時停止・早送り・4倍速）を並べる
+func addReplayControls(game *Game, buttonRow, column *widget.Container) *replayControlsUI {
+	c := game.Config.UI
+	controls := &replayControlsUI{}
+	seek := func(deltaTicks int) {
+		if err := game.seekReplay(game.TickCount + deltaTicks); err != nil {
+			log.Printf("リプレイのシークに失敗しました: %v", err)
+			game.ui.ShowToast(T("replay.seek_failed", "error", err))
+		}
+	}
+	seekTicks := replaySeekSeconds * SimulationTicksPerSecond
+
+	buttonRow.AddChild(newMenuButton(game, T("replay.restart"), func() {
+		seek(-game.TickCount)
+	}))
+	buttonRow.AddChild(newMenuButton(game, T("replay.back", "seconds", replaySeekSeconds), func() {
+		seek(-seekTicks)
+	}))
+	controls.pauseButton = newMenuButton(game, T("replay.pause"), func() {
+		if game.replay.paused && game.replay.atEnd(game.TickCount) {
+			return
+		}
+		game.replay.paused = !game.replay.paused
+	})
+	buttonRow.AddChild(controls.pauseButton)
+	buttonRow.AddChild(newMenuButton(game, T("replay.forward", "seconds", replaySeekSeconds), func() {
+		seek(seekTicks)
+	}))
+	controls.fastForwardButton = newMenuButton(game, T("replay.fast", "speed", replayFastForwardSpeed), func() {
+		game.replay.fastForward = !game.replay.fastForward
+	})
+	buttonRow.AddChild(controls.fastForwardButton)
+
+	controls.statusText = widget.NewText(
+		widget.TextOpts.Text("", game.MplusFont, c.Colors.White),
+		widget.TextOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+			Position: widget.RowLayoutPositionCenter,
+		})),
+	)
+	column.AddChild(controls.statusText)
+	return controls
+}
+
+// updateReplayControls はボタンの表示と再生位置のテキストを現在の再生状態に合わせる
+func updateReplayControls(game *Game, controls *replayControlsUI) {
+	p := game.replay
+	if p.paused {
+		controls.pauseButton.Text().Label = T("replay.play")
+	} else {
+		controls.pauseButton.Text().Label = T("replay.pause")
+	}
+	if p.fastForward {
+		controls.fastForwardButton.Text().Label = T("replay.normal_speed")
+	} else {
+		controls.fastForwardButton.Text().Label = T("replay.fast", "speed", replayFastForwardSpeed)
+	}
+	controls.statusText.Label = T("replay.status",
+		"time", fmt.Sprintf("%.1f", float64(game.TickCount)/SimulationTicksPerSecond),
+		"total", fmt.Sprintf("%.1f", float64(p.data.FinalTick)/SimulationTicksPerSecond),
+		"message", game.message)
+}
+
+// newMenuButton はメニューバー用の小さなボタンを生成する
+func newMenuButton(game *Game, label string, onClick func()) *widget.Button {
+	c := game.Config.UI
+	return widget.NewButton(
+		widget.ButtonOpts.Image(&widget.ButtonImage{
+			Idle:    image.NewNineSliceColor(c.Colors.Gray),
+			Hover:   image.NewNineSliceColor(color.RGBA{180, 180, 180, 255}),
+			Pressed: image.NewNineSliceColor(color.RGBA{100, 100, 100, 255}),
+		}),
+		widget.ButtonOpts.Text(label, game.MplusFont, &widget.ButtonTextColor{
+			Idle: c.Colors.White,
+		}),
+		widget.ButtonOpts.TextPadding(widget.Insets{Top: 4, Left: 12, Right: 12, Bottom: 4}),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			onClick()
+		}),
+	)
+}
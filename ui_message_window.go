@@ -35,7 +35,7 @@ func createMessageWindow(game *Game) widget.PreferredSizeLocateableWidget {
 		widget.TextOpts.Text(game.message, game.MplusFont, c.Colors.White),
 	))
 	panel.AddChild(widget.NewText(
-		widget.TextOpts.Text("クリックして続行...", game.MplusFont, c.Colors.Gray),
+		widget.TextOpts.Text(T("message.continue"), game.MplusFont, c.Colors.Gray),
 		widget.TextOpts.Position(widget.TextPositionEnd, widget.TextPositionEnd),
 	))
 